@@ -41,6 +41,10 @@ type Accumulator interface {
 	// AddMetric adds an metric to the accumulator.
 	AddMetric(Metric)
 
+	// SetFieldMeta sets the unit and description of a field, it is attached
+	// to the field of all metrics with the measurement name added afterwards.
+	SetFieldMeta(measurement, field string, meta FieldMeta)
+
 	// SetPrecision sets the timestamp rounding precision.  All metrics addeds
 	// added to the accumulator will have their timestamp rounded to the
 	// nearest multiple of precision.
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"log"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -22,6 +23,10 @@ type accumulator struct {
 	maker     MetricMaker
 	metrics   chan<- telegraf.Metric
 	precision time.Duration
+
+	sync.RWMutex
+	// meta is the field metadata by measurement and field key.
+	meta map[string]map[string]telegraf.FieldMeta
 }
 
 func NewAccumulator(
@@ -83,6 +88,7 @@ func (ac *accumulator) AddHistogram(
 
 func (ac *accumulator) AddMetric(m telegraf.Metric) {
 	m.SetTime(m.Time().Round(ac.precision))
+	ac.setFieldMeta(m)
 	if m := ac.maker.MakeMetric(m); m != nil {
 		ac.metrics <- m
 	}
@@ -99,11 +105,43 @@ func (ac *accumulator) addFields(
 	if err != nil {
 		return
 	}
+	ac.setFieldMeta(m)
 	if m := ac.maker.MakeMetric(m); m != nil {
 		ac.metrics <- m
 	}
 }
 
+func (ac *accumulator) SetFieldMeta(measurement, field string, meta telegraf.FieldMeta) {
+	ac.Lock()
+	defer ac.Unlock()
+	if ac.meta == nil {
+		ac.meta = make(map[string]map[string]telegraf.FieldMeta)
+	}
+	if ac.meta[measurement] == nil {
+		ac.meta[measurement] = make(map[string]telegraf.FieldMeta)
+	}
+	ac.meta[measurement][field] = meta
+}
+
+// setFieldMeta attaches the field metadata to the metric, metadata already
+// set on the metric is kept.
+func (ac *accumulator) setFieldMeta(m telegraf.Metric) {
+	ac.RLock()
+	defer ac.RUnlock()
+	meta, ok := ac.meta[m.Name()]
+	if !ok {
+		return
+	}
+	for _, field := range m.FieldList() {
+		if _, ok := m.GetFieldMeta(field.Key); ok {
+			continue
+		}
+		if fm, ok := meta[field.Key]; ok {
+			m.SetFieldMeta(field.Key, fm)
+		}
+	}
+}
+
 // AddError passes a runtime error to the accumulator.
 // The error will be tagged with the plugin name and written to the log.
 func (ac *accumulator) AddError(err error) {
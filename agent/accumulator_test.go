@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -45,6 +46,38 @@ func TestAddFields(t *testing.T) {
 	require.Equal(t, telegraf.Counter, tp)
 }
 
+func TestSetFieldMeta(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	a := NewAccumulator(&TestMetricMaker{}, metrics)
+
+	usage := telegraf.FieldMeta{Unit: "percent", Description: "CPU usage"}
+	a.SetFieldMeta("acctest", "usage", usage)
+	a.SetFieldMeta("acctest", "idle", telegraf.FieldMeta{Unit: "percent"})
+
+	a.AddFields("acctest", map[string]interface{}{"usage": float64(99)}, nil)
+	testm := <-metrics
+	meta, ok := testm.GetFieldMeta("usage")
+	require.True(t, ok)
+	require.Equal(t, usage, meta)
+	_, ok = testm.GetFieldMeta("idle")
+	require.False(t, ok)
+
+	// metadata set on the metric is kept
+	m, err := metric.New("acctest", nil, map[string]interface{}{"usage": float64(99)}, time.Now())
+	require.NoError(t, err)
+	m.SetFieldMeta("usage", telegraf.FieldMeta{Unit: "ratio"})
+	a.AddMetric(m)
+	testm = <-metrics
+	meta, _ = testm.GetFieldMeta("usage")
+	require.Equal(t, "ratio", meta.Unit)
+
+	a.AddFields("other", map[string]interface{}{"usage": float64(99)}, nil)
+	testm = <-metrics
+	_, ok = testm.GetFieldMeta("usage")
+	require.False(t, ok)
+}
+
 func TestAccAddError(t *testing.T) {
 	errBuf := bytes.NewBuffer(nil)
 	log.SetOutput(errBuf)
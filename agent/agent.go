@@ -18,12 +18,29 @@ import (
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	mu sync.Mutex
+	// services holds the relays of the started service inputs.
+	services map[*models.RunningInput]*serviceRelay
+	// outputs holds the outputs that are connected and have their buffer
+	// opened.
+	outputs map[*models.RunningOutput]bool
+	// handedOver holds the plugins moved to the agent of a reloaded
+	// configuration, they are left running when this agent stops.
+	handedOver map[interface{}]bool
+	// triggers holds the channels to gather an input or flush an output
+	// immediately.
+	triggers map[interface{}]chan struct{}
 }
 
 // NewAgent returns an Agent for the given Config.
 func NewAgent(config *config.Config) (*Agent, error) {
 	a := &Agent{
-		Config: config,
+		Config:     config,
+		services:   make(map[*models.RunningInput]*serviceRelay),
+		outputs:    make(map[*models.RunningOutput]bool),
+		handedOver: make(map[interface{}]bool),
+		triggers:   make(map[interface{}]chan struct{}),
 	}
 	return a, nil
 }
@@ -39,15 +56,31 @@ func (a *Agent) Run(ctx context.Context) error {
 		return ctx.Err()
 	}
 
+	log.Printf("D! [agent] Opening output buffers")
+	err := a.openBuffers()
+	if err != nil {
+		return err
+	}
+
 	log.Printf("D! [agent] Connecting outputs")
-	err := a.connectOutputs(ctx)
+	err = a.connectOutputs(ctx)
 	if err != nil {
 		return err
 	}
 
+	if a.Config.Agent.APIAddress != "" {
+		api := newAPI(a)
+		err = api.start(a.Config.Agent.APIAddress)
+		if err != nil {
+			return fmt.Errorf("starting management API: %v", err)
+		}
+		defer api.stop()
+	}
+
 	inputC := make(chan telegraf.Metric, 100)
 	procC := make(chan telegraf.Metric, 100)
 	outputC := make(chan telegraf.Metric, 100)
+	postProcC := make(chan telegraf.Metric, 100)
 
 	startTime := time.Now()
 
@@ -87,7 +120,7 @@ func (a *Agent) Run(ctx context.Context) error {
 		go func(src, dst chan telegraf.Metric) {
 			defer wg.Done()
 
-			err := a.runProcessors(src, dst)
+			err := a.runProcessors(a.Config.Processors, src, dst)
 			if err != nil {
 				log.Printf("E! [agent] Error running processors: %v", err)
 			}
@@ -116,6 +149,24 @@ func (a *Agent) Run(ctx context.Context) error {
 		src = dst
 	}
 
+	if len(a.Config.PostProcessors) > 0 {
+		dst = postProcC
+
+		wg.Add(1)
+		go func(src, dst chan telegraf.Metric) {
+			defer wg.Done()
+
+			err := a.runProcessors(a.Config.PostProcessors, src, dst)
+			if err != nil {
+				log.Printf("E! [agent] Error running post aggregation processors: %v", err)
+			}
+			close(dst)
+			log.Printf("D! [agent] Post aggregation processor channel closed")
+		}(src, dst)
+
+		src = dst
+	}
+
 	wg.Add(1)
 	go func(src chan telegraf.Metric) {
 		defer wg.Done()
@@ -264,8 +315,10 @@ func (a *Agent) gatherOnInterval(
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	gatherNow := a.triggerChan(input)
+	wait := jitter
 	for {
-		err := internal.SleepContext(ctx, internal.RandomDuration(jitter))
+		err := internal.SleepContext(ctx, internal.RandomDuration(wait))
 		if err != nil {
 			return
 		}
@@ -277,7 +330,9 @@ func (a *Agent) gatherOnInterval(
 
 		select {
 		case <-ticker.C:
-			continue
+			wait = jitter
+		case <-gatherNow:
+			wait = 0
 		case <-ctx.Done():
 			return
 		}
@@ -310,26 +365,120 @@ func (a *Agent) gatherOnce(
 	}
 }
 
-// runProcessors applies processors to metrics.
+// runProcessors applies the processors to metrics.
+//
+// Consecutive processors without workers are applied on a single goroutine,
+// as are all processors when none has workers.  A processor with workers is
+// applied on a goroutine per worker, metrics of a series are always sent to
+// the same worker so their order is kept.
 func (a *Agent) runProcessors(
+	processors []*models.RunningProcessor,
 	src <-chan telegraf.Metric,
 	agg chan<- telegraf.Metric,
 ) error {
-	for metric := range src {
-		metrics := a.applyProcessors(metric)
+	var stages [][]*models.RunningProcessor
+	var serial []*models.RunningProcessor
+	for _, processor := range processors {
+		if len(processor.Workers) == 0 {
+			serial = append(serial, processor)
+			continue
+		}
 
-		for _, metric := range metrics {
+		if len(serial) > 0 {
+			stages = append(stages, serial)
+			serial = nil
+		}
+		stages = append(stages, []*models.RunningProcessor{processor})
+	}
+	if len(serial) > 0 {
+		stages = append(stages, serial)
+	}
+
+	if len(stages) == 0 {
+		for metric := range src {
 			agg <- metric
 		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i, stage := range stages {
+		dst := agg
+		var next chan telegraf.Metric
+		if i < len(stages)-1 {
+			next = make(chan telegraf.Metric, 100)
+			dst = next
+		}
+
+		wg.Add(1)
+		go func(stage []*models.RunningProcessor, src <-chan telegraf.Metric, dst chan<- telegraf.Metric) {
+			defer wg.Done()
+
+			if len(stage[0].Workers) > 0 {
+				runWorkers(stage[0], src, dst)
+			} else {
+				runSerial(stage, src, dst)
+			}
+
+			if dst != agg {
+				close(dst)
+			}
+		}(stage, src, dst)
+
+		src = next
 	}
+	wg.Wait()
 
 	return nil
 }
 
+// runSerial applies the processors to the metrics on the current goroutine.
+func runSerial(
+	processors []*models.RunningProcessor,
+	src <-chan telegraf.Metric,
+	dst chan<- telegraf.Metric,
+) {
+	for metric := range src {
+		metrics := applyProcessors(processors, metric)
+
+		for _, metric := range metrics {
+			dst <- metric
+		}
+	}
+}
+
+// runWorkers applies the processor to the metrics on its workers.
+func runWorkers(
+	processor *models.RunningProcessor,
+	src <-chan telegraf.Metric,
+	dst chan<- telegraf.Metric,
+) {
+	var wg sync.WaitGroup
+	workers := make([]chan telegraf.Metric, len(processor.Workers))
+	for i, worker := range processor.Workers {
+		workers[i] = make(chan telegraf.Metric, 100)
+
+		wg.Add(1)
+		go func(worker *models.RunningProcessor, src <-chan telegraf.Metric) {
+			defer wg.Done()
+			runSerial([]*models.RunningProcessor{worker}, src, dst)
+		}(worker, workers[i])
+	}
+
+	for metric := range src {
+		workers[metric.HashID()%uint64(len(workers))] <- metric
+	}
+
+	for _, worker := range workers {
+		close(worker)
+	}
+	wg.Wait()
+}
+
 // applyProcessors applies all processors to a metric.
-func (a *Agent) applyProcessors(m telegraf.Metric) []telegraf.Metric {
+func applyProcessors(processors []*models.RunningProcessor, m telegraf.Metric) []telegraf.Metric {
 	metrics := []telegraf.Metric{m}
-	for _, processor := range a.Config.Processors {
+	for _, processor := range processors {
 		metrics = processor.Apply(metrics...)
 	}
 
@@ -413,15 +562,12 @@ func (a *Agent) runAggregators(
 		close(aggregations)
 	}()
 
-	for metric := range aggregations {
-		metrics := a.applyProcessors(metric)
-		for _, metric := range metrics {
-			dst <- metric
-		}
-	}
+	// Aggregations are processed like the metrics of the inputs, so that
+	// the metrics of a series are sent to the same processor worker.
+	err := a.runProcessors(a.Config.Processors, aggregations, dst)
 
 	wg.Wait()
-	return nil
+	return err
 }
 
 // push runs the push for a single aggregator every period.
@@ -486,9 +632,28 @@ func (a *Agent) runOutputs(
 		}(output)
 	}
 
+	routed := make([]*models.RunningOutput, 0, len(a.Config.Outputs))
 	for metric := range src {
-		for i, output := range a.Config.Outputs {
-			if i == len(a.Config.Outputs)-1 {
+		route, ok := metric.GetTag(models.RouteTag)
+		if ok {
+			metric.RemoveTag(models.RouteTag)
+		}
+
+		routed = routed[:0]
+		for _, output := range a.Config.Outputs {
+			if output.Config.AcceptsRoute(route) {
+				routed = append(routed, output)
+			}
+		}
+
+		if len(routed) == 0 {
+			models.AgentMetricsUnrouted.Incr(1)
+			metric.Drop()
+			continue
+		}
+
+		for i, output := range routed {
+			if i == len(routed)-1 {
 				output.AddMetric(metric)
 			} else {
 				output.AddMetric(metric.Copy())
@@ -516,34 +681,45 @@ func (a *Agent) flush(
 	ticker := NewTicker(interval, jitter)
 	defer ticker.Stop()
 
+	// The circuit breaker logs when it pauses and resumes writes, paused
+	// writes are not logged on every flush.
 	logError := func(err error) {
-		if err != nil {
+		if err != nil && err != models.ErrCircuitOpen {
 			log.Printf("E! [agent] Error writing to output [%s]: %v", output.Name, err)
 		}
 	}
 
+	flushNow := a.triggerChan(output)
+
+	// Failed writes are retried until the context is done, the final flush
+	// writes once without retries so that shutdown is not delayed.
+	write := func() error { return output.WriteContext(ctx) }
+	writeBatch := func() error { return output.WriteBatchContext(ctx) }
+
 	for {
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, write))
 			return
 		default:
 		}
 
 		select {
 		case <-ticker.C:
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, write))
+		case <-flushNow:
+			logError(a.flushOnce(output, interval, write))
 		case <-output.BatchReady:
 			// Favor the ticker over batch ready
 			select {
 			case <-ticker.C:
-				logError(a.flushOnce(output, interval, output.Write))
+				logError(a.flushOnce(output, interval, write))
 			default:
-				logError(a.flushOnce(output, interval, output.WriteBatch))
+				logError(a.flushOnce(output, interval, writeBatch))
 			}
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, write))
 			return
 		}
 	}
@@ -578,9 +754,52 @@ func (a *Agent) flushOnce(
 
 }
 
+// triggerChan returns the channel to gather the input or flush the output
+// plugin immediately.
+func (a *Agent) triggerChan(plugin interface{}) chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	c, ok := a.triggers[plugin]
+	if !ok {
+		c = make(chan struct{}, 1)
+		a.triggers[plugin] = c
+	}
+	return c
+}
+
+// trigger gathers the input or flushes the output plugin as soon as
+// possible.
+func (a *Agent) trigger(plugin interface{}) {
+	select {
+	case a.triggerChan(plugin) <- struct{}{}:
+	default:
+	}
+}
+
+// openBuffers loads the persisted buffers of all outputs.
+func (a *Agent) openBuffers() error {
+	for _, output := range a.Config.Outputs {
+		if a.isStarted(output) {
+			continue
+		}
+
+		err := output.OpenBuffer()
+		if err != nil {
+			return fmt.Errorf("could not open buffer of output %s: %v", output.Name, err)
+		}
+	}
+	return nil
+}
+
 // connectOutputs connects to all outputs.
 func (a *Agent) connectOutputs(ctx context.Context) error {
 	for _, output := range a.Config.Outputs {
+		if a.isStarted(output) {
+			log.Printf("D! [agent] Output %s is already connected\n", output.Name)
+			continue
+		}
+
 		log.Printf("D! [agent] Attempting connection to output: %s\n", output.Name)
 		err := output.Output.Connect()
 		if err != nil {
@@ -598,58 +817,107 @@ func (a *Agent) connectOutputs(ctx context.Context) error {
 			}
 		}
 		log.Printf("D! [agent] Successfully connected to output: %s\n", output.Name)
+
+		a.mu.Lock()
+		a.outputs[output] = true
+		a.mu.Unlock()
 	}
 	return nil
 }
 
-// closeOutputs closes all outputs.
+func (a *Agent) isStarted(output *models.RunningOutput) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.outputs[output]
+}
+
+// closeOutputs closes all outputs, except those handed over to another agent.
 func (a *Agent) closeOutputs() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	for _, output := range a.Config.Outputs {
+		if a.handedOver[output] {
+			continue
+		}
 		output.Close()
+		delete(a.outputs, output)
 	}
 }
 
-// startServiceInputs starts all service inputs.
+// startServiceInputs starts all service inputs.  Service inputs handed over
+// from a previous agent are already running, only their metrics are routed
+// to dst.
 func (a *Agent) startServiceInputs(
 	ctx context.Context,
 	dst chan<- telegraf.Metric,
 ) error {
-	started := []telegraf.ServiceInput{}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	started := []*models.RunningInput{}
 
 	for _, input := range a.Config.Inputs {
-		if si, ok := input.Input.(telegraf.ServiceInput); ok {
-			// Service input plugins are not subject to timestamp rounding.
-			// This only applies to the accumulator passed to Start(), the
-			// Gather() accumulator does apply rounding according to the
-			// precision agent setting.
-			acc := NewAccumulator(input, dst)
-			acc.SetPrecision(time.Nanosecond)
-
-			err := si.Start(acc)
-			if err != nil {
-				log.Printf("E! [agent] Service for input %s failed to start: %v",
-					input.Name(), err)
+		si, ok := input.Input.(telegraf.ServiceInput)
+		if !ok {
+			continue
+		}
 
-				for _, si := range started {
-					si.Stop()
-				}
+		if relay, ok := a.services[input]; ok {
+			relay.forward(dst)
+			continue
+		}
 
-				return err
+		// Service input plugins are not subject to timestamp rounding.
+		// This only applies to the accumulator passed to Start(), the
+		// Gather() accumulator does apply rounding according to the
+		// precision agent setting.
+		relay := newServiceRelay()
+		acc := NewAccumulator(input, relay.metrics)
+		acc.SetPrecision(time.Nanosecond)
+
+		err := si.Start(acc)
+		if err != nil {
+			log.Printf("E! [agent] Service for input %s failed to start: %v",
+				input.Name(), err)
+
+			for _, input := range started {
+				input.Input.(telegraf.ServiceInput).Stop()
+				a.services[input].close()
+				delete(a.services, input)
 			}
 
-			started = append(started, si)
+			return err
 		}
+
+		relay.forward(dst)
+		a.services[input] = relay
+		started = append(started, input)
 	}
 
 	return nil
 }
 
-// stopServiceInputs stops all service inputs.
+// stopServiceInputs stops all service inputs, except those handed over to
+// another agent which are only detached from this agent.
 func (a *Agent) stopServiceInputs() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	for _, input := range a.Config.Inputs {
-		if si, ok := input.Input.(telegraf.ServiceInput); ok {
-			si.Stop()
+		relay, ok := a.services[input]
+		if !ok {
+			continue
 		}
+
+		if a.handedOver[input] {
+			relay.detach()
+			continue
+		}
+
+		input.Input.(telegraf.ServiceInput).Stop()
+		relay.close()
+		delete(a.services, input)
 	}
 }
 
@@ -1,10 +1,15 @@
 package agent
 
 import (
+	"fmt"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/metric"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	"github.com/stretchr/testify/assert"
@@ -166,3 +171,288 @@ func TestWindow(t *testing.T) {
 		})
 	}
 }
+
+type routeOutput struct {
+	metrics []telegraf.Metric
+}
+
+func (o *routeOutput) Connect() error       { return nil }
+func (o *routeOutput) Close() error         { return nil }
+func (o *routeOutput) Description() string  { return "" }
+func (o *routeOutput) SampleConfig() string { return "" }
+func (o *routeOutput) Write(metrics []telegraf.Metric) error {
+	o.metrics = append(o.metrics, metrics...)
+	return nil
+}
+
+func TestAgent_RunOutputsRoutes(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.RoundInterval = false
+	outputs := make([]*routeOutput, 3)
+	for i, routes := range [][]string{nil, {"a"}, {"a", "b"}} {
+		outputs[i] = &routeOutput{}
+		oc := &models.OutputConfig{Name: "route", Routes: routes}
+		require.NoError(t, oc.CompileRoutes())
+		c.Outputs = append(c.Outputs,
+			models.NewRunningOutput("route", outputs[i], oc, 0, 0))
+	}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	src := make(chan telegraf.Metric, 10)
+	for _, route := range []string{"none", "a", "b", "c"} {
+		tags := map[string]string{}
+		if route != "none" {
+			tags[models.RouteTag] = route
+		}
+		m, err := metric.New("cpu", tags,
+			map[string]interface{}{"route": route}, time.Unix(0, 0))
+		require.NoError(t, err)
+		src <- m
+	}
+	close(src)
+
+	require.NoError(t, a.runOutputs(time.Now(), src))
+
+	routes := func(o *routeOutput) []string {
+		var routes []string
+		for _, m := range o.metrics {
+			require.False(t, m.HasTag(models.RouteTag))
+			route, _ := m.GetField("route")
+			routes = append(routes, route.(string))
+		}
+		sort.Strings(routes)
+		return routes
+	}
+	require.Equal(t, []string{"none"}, routes(outputs[0]))
+	require.Equal(t, []string{"a"}, routes(outputs[1]))
+	require.Equal(t, []string{"a", "b"}, routes(outputs[2]))
+}
+
+type workerProcessor struct {
+	name string
+}
+
+func (p *workerProcessor) SampleConfig() string { return "" }
+func (p *workerProcessor) Description() string  { return "" }
+func (p *workerProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		v, _ := m.GetField("processors")
+		m.AddField("processors", v.(string)+p.name)
+	}
+	return in
+}
+
+func TestAgent_RunProcessorsWorkers(t *testing.T) {
+	c := config.NewConfig()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		conf := &models.ProcessorConfig{Name: name}
+		rp := &models.RunningProcessor{
+			Name:      name,
+			Processor: &workerProcessor{name: name},
+			Config:    conf,
+		}
+		if name == "b" {
+			conf.Workers = 3
+			rp.Workers = []*models.RunningProcessor{rp}
+			for i := 1; i < conf.Workers; i++ {
+				rp.Workers = append(rp.Workers, &models.RunningProcessor{
+					Name:      name,
+					Processor: &workerProcessor{name: name},
+					Config:    conf,
+				})
+			}
+		}
+		c.Processors = append(c.Processors, rp)
+	}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	src := make(chan telegraf.Metric)
+	dst := make(chan telegraf.Metric, 1000)
+	go func() {
+		for i := 0; i < 100; i++ {
+			m, err := metric.New("cpu",
+				map[string]string{"series": fmt.Sprint(i % 10)},
+				map[string]interface{}{"processors": "", "n": int64(i)},
+				time.Unix(0, 0))
+			require.NoError(t, err)
+			src <- m
+		}
+		close(src)
+	}()
+
+	require.NoError(t, a.runProcessors(c.Processors, src, dst))
+	close(dst)
+
+	last := make(map[string]int64)
+	var count int
+	for m := range dst {
+		count++
+		v, _ := m.GetField("processors")
+		require.Equal(t, "abcd", v)
+
+		series, _ := m.GetTag("series")
+		n, _ := m.GetField("n")
+		if prev, ok := last[series]; ok {
+			require.True(t, n.(int64) > prev, "series %s out of order", series)
+		}
+		last[series] = n.(int64)
+	}
+	require.Equal(t, 100, count)
+}
+
+type indexProcessor struct {
+	index int64
+}
+
+func (p *indexProcessor) SampleConfig() string { return "" }
+func (p *indexProcessor) Description() string  { return "" }
+func (p *indexProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.AddField("worker", p.index)
+	}
+	return in
+}
+
+// passAggregator pushes the metrics added to it.
+type passAggregator struct {
+	metrics []telegraf.Metric
+}
+
+func (a *passAggregator) SampleConfig() string { return "" }
+func (a *passAggregator) Description() string  { return "" }
+func (a *passAggregator) Add(m telegraf.Metric) {
+	a.metrics = append(a.metrics, m)
+}
+func (a *passAggregator) Push(acc telegraf.Accumulator) {
+	for _, m := range a.metrics {
+		acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+	}
+}
+func (a *passAggregator) Reset() {
+	a.metrics = nil
+}
+
+func TestAgent_RunAggregatorsWorkers(t *testing.T) {
+	c := config.NewConfig()
+	conf := &models.ProcessorConfig{Name: "index", Workers: 3}
+	rp := &models.RunningProcessor{
+		Name:      "index",
+		Processor: &indexProcessor{index: 0},
+		Config:    conf,
+	}
+	rp.Workers = []*models.RunningProcessor{rp}
+	for i := 1; i < conf.Workers; i++ {
+		rp.Workers = append(rp.Workers, &models.RunningProcessor{
+			Name:      "index",
+			Processor: &indexProcessor{index: int64(i)},
+			Config:    conf,
+		})
+	}
+	c.Processors = append(c.Processors, rp)
+	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(
+		&passAggregator{},
+		&models.AggregatorConfig{
+			Name:         "pass",
+			DropOriginal: true,
+			Period:       time.Hour,
+		}))
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	src := make(chan telegraf.Metric)
+	dst := make(chan telegraf.Metric, 1000)
+	startTime := time.Now()
+	go func() {
+		for i := 0; i < 10; i++ {
+			m, err := metric.New("cpu",
+				map[string]string{"series": fmt.Sprint(i)},
+				map[string]interface{}{"n": int64(i)},
+				startTime)
+			require.NoError(t, err)
+			src <- m
+		}
+		close(src)
+	}()
+
+	require.NoError(t, a.runAggregators(startTime, src, dst))
+	close(dst)
+
+	// the aggregations of a series are processed by the worker of the
+	// series
+	var count int
+	for m := range dst {
+		count++
+		worker, ok := m.GetField("worker")
+		require.True(t, ok)
+		require.Equal(t, int64(m.HashID()%3), worker)
+	}
+	require.Equal(t, 10, count)
+}
+
+func TestAgent_PostAggregationProcessors(t *testing.T) {
+	c := config.NewConfig()
+	c.Processors = append(c.Processors, &models.RunningProcessor{
+		Name:      "pre",
+		Processor: &workerProcessor{name: "a"},
+		Config:    &models.ProcessorConfig{Name: "pre"},
+	})
+	c.PostProcessors = append(c.PostProcessors, &models.RunningProcessor{
+		Name:      "post",
+		Processor: &workerProcessor{name: "z"},
+		Config: &models.ProcessorConfig{
+			Name:  "post",
+			Stage: models.StagePostAggregation,
+		},
+	})
+	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(
+		&passAggregator{},
+		&models.AggregatorConfig{
+			Name:   "pass",
+			Period: time.Hour,
+		}))
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	// the stages are connected as in Run
+	src := make(chan telegraf.Metric)
+	procC := make(chan telegraf.Metric, 100)
+	aggC := make(chan telegraf.Metric, 100)
+	dst := make(chan telegraf.Metric, 100)
+	startTime := time.Now()
+	go func() {
+		require.NoError(t, a.runProcessors(c.Processors, src, procC))
+		close(procC)
+	}()
+	go func() {
+		require.NoError(t, a.runAggregators(startTime, procC, aggC))
+		close(aggC)
+	}()
+	go func() {
+		require.NoError(t, a.runProcessors(c.PostProcessors, aggC, dst))
+		close(dst)
+	}()
+
+	m, err := metric.New("cpu",
+		map[string]string{},
+		map[string]interface{}{"processors": ""},
+		startTime)
+	require.NoError(t, err)
+	src <- m
+	close(src)
+
+	// The aggregator sees the metric after the pre aggregation processors.
+	// The pre aggregation processors are applied again to its output, the
+	// post aggregation processors once to all metrics.
+	processors := make(map[bool]interface{})
+	for m := range dst {
+		processors[m.IsAggregate()], _ = m.GetField("processors")
+	}
+	require.Equal(t,
+		map[bool]interface{}{
+			false: "az",
+			true:  "aaz",
+		},
+		processors)
+}
@@ -0,0 +1,462 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// api serves the management API of an Agent:
+//
+//	GET  /plugins  loaded plugins with their settings and stats
+//	GET  /stats    all internal stats
+//	POST /gather   gather inputs now, optionally only the inputs ?name=
+//	POST /flush    flush outputs now, optionally only the outputs ?name=
+type api struct {
+	agent  *Agent
+	server *http.Server
+
+	// settings holds the settings of each plugin, they are collected when
+	// the api is created as plugins may modify them while running.
+	settings map[interface{}]map[string]interface{}
+}
+
+type pluginInfo struct {
+	Name     string                            `json:"name"`
+	Settings map[string]interface{}            `json:"config"`
+	Stats    map[string]map[string]interface{} `json:"stats,omitempty"`
+	Buffer   *bufferInfo                       `json:"buffer,omitempty"`
+}
+
+type bufferInfo struct {
+	Size  int `json:"size"`
+	Limit int `json:"limit"`
+}
+
+type pluginList struct {
+	Inputs      []pluginInfo `json:"inputs"`
+	Processors  []pluginInfo `json:"processors"`
+	Aggregators []pluginInfo `json:"aggregators"`
+	Outputs     []pluginInfo `json:"outputs"`
+}
+
+type statInfo struct {
+	Name   string                 `json:"name"`
+	Tags   map[string]string      `json:"tags"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+func newAPI(a *Agent) *api {
+	api := &api{
+		agent:    a,
+		settings: make(map[interface{}]map[string]interface{}),
+	}
+
+	for _, input := range a.Config.Inputs {
+		settings := pluginSettings(input.Input)
+		if input.Config.Interval != 0 {
+			settings["interval"] = input.Config.Interval.String()
+		}
+		api.settings[input] = settings
+	}
+	for _, processor := range allProcessors(a) {
+		settings := pluginSettings(processor.Processor)
+		settings["order"] = processor.Config.Order
+		if processor.Config.Workers > 0 {
+			settings["workers"] = processor.Config.Workers
+		}
+		if processor.Config.Stage != "" {
+			settings["stage"] = processor.Config.Stage
+		}
+		api.settings[processor] = settings
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		settings := pluginSettings(aggregator.Aggregator)
+		settings["period"] = aggregator.Config.Period.String()
+		settings["delay"] = aggregator.Config.Delay.String()
+		settings["drop_original"] = aggregator.Config.DropOriginal
+		api.settings[aggregator] = settings
+	}
+	for _, output := range a.Config.Outputs {
+		settings := pluginSettings(output.Output)
+		if output.Config.FlushInterval != 0 {
+			settings["flush_interval"] = output.Config.FlushInterval.String()
+		}
+		settings["metric_batch_size"] = output.MetricBatchSize
+		settings["metric_buffer_limit"] = output.MetricBufferLimit
+		if output.Config.BufferPath != "" {
+			settings["buffer_path"] = output.Config.BufferPath
+		}
+		api.settings[output] = settings
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plugins", api.servePlugins)
+	mux.HandleFunc("/stats", api.serveStats)
+	mux.HandleFunc("/gather", api.serveGather)
+	mux.HandleFunc("/flush", api.serveFlush)
+	api.server = &http.Server{
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	return api
+}
+
+// start listens on address and serves the api until stop is called.
+func (api *api) start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := api.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] Error serving management API: %v", err)
+		}
+	}()
+
+	log.Printf("I! [agent] Management API listening on %s", listener.Addr())
+	return nil
+}
+
+func (api *api) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	api.server.Shutdown(ctx)
+}
+
+func (api *api) servePlugins(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	stats := make(map[string]map[string]map[string]interface{})
+	for _, m := range selfstat.Metrics() {
+		if m == nil {
+			continue
+		}
+		for _, key := range []string{"input", "aggregator", "output"} {
+			name, ok := m.GetTag(key)
+			if !ok {
+				continue
+			}
+
+			id := key + "." + name
+			if stats[id] == nil {
+				stats[id] = make(map[string]map[string]interface{})
+			}
+			stats[id][strings.TrimPrefix(m.Name(), "internal_")] = m.Fields()
+		}
+	}
+
+	a := api.agent
+	list := pluginList{
+		Inputs:      []pluginInfo{},
+		Processors:  []pluginInfo{},
+		Aggregators: []pluginInfo{},
+		Outputs:     []pluginInfo{},
+	}
+	for _, input := range a.Config.Inputs {
+		list.Inputs = append(list.Inputs, pluginInfo{
+			Name:     input.Config.Name,
+			Settings: api.settings[input],
+			Stats:    stats["input."+input.Config.Name],
+		})
+	}
+	for _, processor := range allProcessors(a) {
+		list.Processors = append(list.Processors, pluginInfo{
+			Name:     processor.Config.Name,
+			Settings: api.settings[processor],
+		})
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		list.Aggregators = append(list.Aggregators, pluginInfo{
+			Name:     aggregator.Config.Name,
+			Settings: api.settings[aggregator],
+			Stats:    stats["aggregator."+aggregator.Config.Name],
+		})
+	}
+	for _, output := range a.Config.Outputs {
+		list.Outputs = append(list.Outputs, pluginInfo{
+			Name:     output.Config.Name,
+			Settings: api.settings[output],
+			Stats:    stats["output."+output.Config.Name],
+			Buffer: &bufferInfo{
+				Size:  output.BufferLength(),
+				Limit: output.MetricBufferLimit,
+			},
+		})
+	}
+
+	writeJSON(w, list)
+}
+
+func (api *api) serveStats(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	stats := []statInfo{}
+	for _, m := range selfstat.Metrics() {
+		if m == nil {
+			continue
+		}
+		stats = append(stats, statInfo{
+			Name:   m.Name(),
+			Tags:   m.Tags(),
+			Fields: m.Fields(),
+		})
+	}
+
+	writeJSON(w, stats)
+}
+
+func (api *api) serveGather(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	var n int
+	for _, input := range api.agent.Config.Inputs {
+		if name == "" || name == input.Config.Name {
+			api.agent.trigger(input)
+			n++
+		}
+	}
+	triggered(w, n)
+}
+
+func (api *api) serveFlush(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	var n int
+	for _, output := range api.agent.Config.Outputs {
+		if name == "" || name == output.Config.Name {
+			api.agent.trigger(output)
+			n++
+		}
+	}
+	triggered(w, n)
+}
+
+// allProcessors returns the processors of both stages.
+func allProcessors(a *Agent) []*models.RunningProcessor {
+	processors := make([]*models.RunningProcessor, 0,
+		len(a.Config.Processors)+len(a.Config.PostProcessors))
+	processors = append(processors, a.Config.Processors...)
+	return append(processors, a.Config.PostProcessors...)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+func triggered(w http.ResponseWriter, n int) {
+	if n == 0 {
+		http.Error(w, "no matching plugin", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("E! [agent] Error writing management API response: %v", err)
+	}
+}
+
+// secretSettings are the substrings of setting names whose value is not
+// shown by the api.
+var secretSettings = []string{
+	"password", "passwd", "secret", "token", "credential",
+	"api_key", "apikey", "access_key", "private_key", "authorization",
+}
+
+var (
+	// userinfoPassword matches the password of the user info of URLs, and
+	// of DSNs such as "user:password@tcp(localhost:3306)/".
+	userinfoPassword = regexp.MustCompile(`^((?:[a-zA-Z][a-zA-Z0-9+.-]*://)?[^:@/\s]*:)[^@/\s]*@`)
+
+	// keyPassword matches the password of key value DSNs such as
+	// "host=localhost password=secret", and of URL query parameters.
+	keyPassword = regexp.MustCompile(`(?i)\b((?:password|pwd)\s*=\s*)(?:'[^']*'|"[^"]*"|[^\s;&]*)`)
+)
+
+// pluginSettings returns the exported settings of a plugin keyed by their
+// toml name.  Secrets, including the passwords in URLs and DSNs and the
+// values of headers, are masked and values that cannot be encoded as JSON
+// are left out.
+func pluginSettings(plugin interface{}) map[string]interface{} {
+	settings := make(map[string]interface{})
+	v := reflect.Indirect(reflect.ValueOf(plugin))
+	if v.Kind() == reflect.Struct {
+		addSettings(settings, v)
+	}
+	return settings
+}
+
+func addSettings(settings map[string]interface{}, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Anonymous {
+			value = reflect.Indirect(value)
+			if value.Kind() == reflect.Struct {
+				addSettings(settings, value)
+			}
+			continue
+		}
+
+		// unexported
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = internal.SnakeCase(field.Name)
+		}
+
+		if !value.CanInterface() {
+			continue
+		}
+
+		switch value.Kind() {
+		case reflect.Func, reflect.Chan, reflect.Interface, reflect.UnsafePointer:
+			continue
+		}
+
+		if isSecret(name) {
+			if value.Kind() != reflect.String || value.Len() > 0 {
+				settings[name] = "******"
+			}
+			continue
+		}
+
+		setting, ok := settingValue(name, value)
+		if !ok {
+			continue
+		}
+		if _, err := json.Marshal(setting); err != nil {
+			continue
+		}
+		settings[name] = setting
+	}
+}
+
+// settingValue returns the value of the setting name as shown by the api.
+// Nested structs, slices and maps are converted recursively, map values with
+// a secret key and all header values are masked, and values of other kinds
+// are left out.
+func settingValue(name string, v reflect.Value) (interface{}, bool) {
+	switch x := v.Interface().(type) {
+	case internal.Duration:
+		return x.Duration.String(), true
+	case internal.Size:
+		return x.Size, true
+	}
+
+	switch v.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return v.Interface(), true
+	case reflect.String:
+		return maskPasswords(v.String()), true
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, true
+		}
+		return settingValue(name, v.Elem())
+	case reflect.Struct:
+		settings := make(map[string]interface{})
+		addSettings(settings, v)
+		return settings, true
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, true
+		}
+		// keep []string typed as it is the most common list setting
+		if v.Type().Elem().Kind() == reflect.String {
+			masked := make([]string, v.Len())
+			for i := range masked {
+				masked[i] = maskPasswords(v.Index(i).String())
+			}
+			return masked, true
+		}
+		values := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, ok := settingValue(name, v.Index(i))
+			if !ok {
+				return nil, false
+			}
+			values = append(values, value)
+		}
+		return values, true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		headers := strings.Contains(strings.ToLower(name), "header")
+		values := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			if headers || isSecret(key.String()) {
+				values[key.String()] = "******"
+				continue
+			}
+			value, ok := settingValue(name, v.MapIndex(key))
+			if !ok {
+				return nil, false
+			}
+			values[key.String()] = value
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// maskPasswords masks the passwords in a URL or DSN setting.
+func maskPasswords(s string) string {
+	s = userinfoPassword.ReplaceAllString(s, "${1}******@")
+	return keyPassword.ReplaceAllString(s, "${1}******")
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretSettings {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"log"
+	"reflect"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// Reload returns an Agent for the reloaded Config c.
+//
+// Plugins with a configuration identical to a plugin of this agent are moved
+// to the new agent: their instances replace the ones in c, outputs stay
+// connected and keep the metrics in their buffer, and service inputs keep
+// running.  All other plugins are started by the new agent.  This agent must
+// be stopped before the returned agent is run.
+func (a *Agent) Reload(c *config.Config) (*Agent, error) {
+	next, err := NewAgent(c)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !reflect.DeepEqual(a.Config.Agent, c.Agent) ||
+		!reflect.DeepEqual(a.Config.Tags, c.Tags) {
+		log.Printf("I! [agent] Agent settings or global tags changed, restarting all plugins")
+		return next, nil
+	}
+
+	var kept, restarted int
+	keep := func(old interface{}) {
+		a.handedOver[old] = true
+		kept++
+	}
+
+	inputs := make([]*models.RunningInput, len(a.Config.Inputs))
+	copy(inputs, a.Config.Inputs)
+	for i, input := range c.Inputs {
+		old := takeInput(inputs, input)
+		if old == nil {
+			restarted++
+			continue
+		}
+
+		c.Inputs[i] = old
+		if relay, ok := a.services[old]; ok {
+			next.services[old] = relay
+		}
+		keep(old)
+	}
+
+	outputs := make([]*models.RunningOutput, len(a.Config.Outputs))
+	copy(outputs, a.Config.Outputs)
+	for i, output := range c.Outputs {
+		old := takeOutput(outputs, output)
+		if old == nil {
+			restarted++
+			continue
+		}
+
+		c.Outputs[i] = old
+		if a.outputs[old] {
+			next.outputs[old] = true
+		}
+		keep(old)
+	}
+
+	keepProcessors := func(current, reloaded []*models.RunningProcessor) {
+		processors := make([]*models.RunningProcessor, len(current))
+		copy(processors, current)
+		for i, processor := range reloaded {
+			old := takeProcessor(processors, processor)
+			if old == nil {
+				restarted++
+				continue
+			}
+
+			reloaded[i] = old
+			keep(old)
+		}
+	}
+	keepProcessors(a.Config.Processors, c.Processors)
+	keepProcessors(a.Config.PostProcessors, c.PostProcessors)
+
+	aggregators := make([]*models.RunningAggregator, len(a.Config.Aggregators))
+	copy(aggregators, a.Config.Aggregators)
+	for i, aggregator := range c.Aggregators {
+		old := takeAggregator(aggregators, aggregator)
+		if old == nil {
+			restarted++
+			continue
+		}
+
+		c.Aggregators[i] = old
+		keep(old)
+	}
+
+	log.Printf("I! [agent] Reloading config, %d plugins unchanged, %d plugins restarted",
+		kept, restarted)
+	return next, nil
+}
+
+// takeInput removes and returns the first input with the same configuration
+// as input, or nil if there is none.
+func takeInput(inputs []*models.RunningInput, input *models.RunningInput) *models.RunningInput {
+	for i, old := range inputs {
+		if old != nil && old.Config.Name == input.Config.Name &&
+			old.Hash == input.Hash {
+			inputs[i] = nil
+			return old
+		}
+	}
+	return nil
+}
+
+func takeOutput(outputs []*models.RunningOutput, output *models.RunningOutput) *models.RunningOutput {
+	for i, old := range outputs {
+		if old != nil && old.Config.Name == output.Config.Name &&
+			old.Hash == output.Hash {
+			outputs[i] = nil
+			return old
+		}
+	}
+	return nil
+}
+
+func takeProcessor(processors []*models.RunningProcessor, processor *models.RunningProcessor) *models.RunningProcessor {
+	for i, old := range processors {
+		if old != nil && old.Config.Name == processor.Config.Name &&
+			old.Hash == processor.Hash {
+			processors[i] = nil
+			return old
+		}
+	}
+	return nil
+}
+
+func takeAggregator(aggregators []*models.RunningAggregator, aggregator *models.RunningAggregator) *models.RunningAggregator {
+	for i, old := range aggregators {
+		if old != nil && old.Config.Name == aggregator.Config.Name &&
+			old.Hash == aggregator.Hash {
+			aggregators[i] = nil
+			return old
+		}
+	}
+	return nil
+}
+
+// serviceRelay passes the metrics of a service input to the agent running
+// it.  A service input keeps its relay for its whole lifetime, so it can be
+// handed over between agents without being restarted.
+type serviceRelay struct {
+	metrics chan telegraf.Metric
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newServiceRelay() *serviceRelay {
+	return &serviceRelay{
+		metrics: make(chan telegraf.Metric, 100),
+	}
+}
+
+// forward starts passing metrics to dst.
+func (r *serviceRelay) forward(dst chan<- telegraf.Metric) {
+	r.done = make(chan struct{})
+	r.wg.Add(1)
+	go func(done chan struct{}) {
+		defer r.wg.Done()
+		for {
+			select {
+			case metric, ok := <-r.metrics:
+				if !ok {
+					return
+				}
+				dst <- metric
+			case <-done:
+				return
+			}
+		}
+	}(r.done)
+}
+
+// detach stops passing metrics, metrics added from now on are held until
+// forward is called again.
+func (r *serviceRelay) detach() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+// close passes all remaining metrics and stops; the service input must be
+// stopped.
+func (r *serviceRelay) close() {
+	close(r.metrics)
+	r.wg.Wait()
+}
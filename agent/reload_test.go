@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestConfig(t *testing.T, toml string) *config.Config {
+	f, err := ioutil.TempFile("", "telegraf-reload")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(toml)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	c := config.NewConfig()
+	c.Agent.OmitHostname = true
+	require.NoError(t, c.LoadConfig(f.Name()))
+	return c
+}
+
+const reloadConfig = `
+[[inputs.file]]
+  files = ["/tmp/a"]
+  data_format = "influx"
+
+[[inputs.file]]
+  files = ["/tmp/b"]
+  data_format = "influx"
+
+[[outputs.file]]
+  files = ["stdout"]
+`
+
+func TestReload_Unchanged(t *testing.T) {
+	a, err := NewAgent(loadTestConfig(t, reloadConfig))
+	require.NoError(t, err)
+
+	c := loadTestConfig(t, reloadConfig)
+	next, err := a.Reload(c)
+	require.NoError(t, err)
+
+	for i, input := range next.Config.Inputs {
+		require.True(t, a.Config.Inputs[i] == input)
+		require.True(t, a.handedOver[input])
+	}
+	for i, output := range next.Config.Outputs {
+		require.True(t, a.Config.Outputs[i] == output)
+		require.True(t, a.handedOver[output])
+	}
+}
+
+func TestReload_ChangedInput(t *testing.T) {
+	a, err := NewAgent(loadTestConfig(t, reloadConfig))
+	require.NoError(t, err)
+
+	c := loadTestConfig(t, `
+[[inputs.file]]
+  data_format = "influx"
+  files = [ "/tmp/a" ]
+
+[[inputs.file]]
+  files = ["/tmp/c"]
+  data_format = "influx"
+
+[[outputs.file]]
+  files = ["stdout"]
+`)
+	next, err := a.Reload(c)
+	require.NoError(t, err)
+
+	var kept, started int
+	for _, input := range next.Config.Inputs {
+		if a.handedOver[input] {
+			kept++
+		} else {
+			started++
+		}
+	}
+	require.Equal(t, 1, kept)
+	require.Equal(t, 1, started)
+	require.True(t, a.Config.Outputs[0] == next.Config.Outputs[0])
+}
+
+func TestReload_AgentChanged(t *testing.T) {
+	a, err := NewAgent(loadTestConfig(t, reloadConfig))
+	require.NoError(t, err)
+
+	c := loadTestConfig(t, `
+[agent]
+  interval = "1s"
+`+reloadConfig)
+	next, err := a.Reload(c)
+	require.NoError(t, err)
+
+	require.Len(t, a.handedOver, 0)
+	for i, output := range next.Config.Outputs {
+		require.False(t, a.Config.Outputs[i] == output)
+	}
+}
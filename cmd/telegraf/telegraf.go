@@ -78,6 +78,21 @@ func reloadLoop(
 	aggregatorFilters []string,
 	processorFilters []string,
 ) {
+	// Setup default logging. This may need to change after reading the config
+	// file, but we can configure it to use our logger implementation now.
+	logger.SetupLogging(logger.LogConfig{})
+	log.Printf("I! Starting Telegraf %s", version)
+
+	c, err := loadConfig(inputFilters, outputFilters)
+	if err != nil {
+		log.Fatalf("E! [telegraf] Error running agent: %v", err)
+	}
+
+	ag, err := agent.NewAgent(c)
+	if err != nil {
+		log.Fatalf("E! [telegraf] Error running agent: %v", err)
+	}
+
 	reload := make(chan bool, 1)
 	reload <- true
 	for <-reload {
@@ -88,51 +103,71 @@ func reloadLoop(
 		signals := make(chan os.Signal)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
-		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config")
-					<-reload
-					reload <- true
+		go func(current *agent.Agent) {
+			for {
+				select {
+				case sig := <-signals:
+					if sig == syscall.SIGHUP {
+						log.Printf("I! Reloading Telegraf config")
+						next, err := reloadAgent(current, inputFilters, outputFilters)
+						if err != nil {
+							log.Printf("E! [telegraf] Error reloading config, "+
+								"keeping current config: %v", err)
+							continue
+						}
+						ag = next
+						<-reload
+						reload <- true
+					}
+					cancel()
+					return
+				case <-stop:
+					cancel()
+					return
 				}
-				cancel()
-			case <-stop:
-				cancel()
 			}
-		}()
+		}(ag)
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+		err := runAgent(ctx, ag)
+		signal.Stop(signals)
 		if err != nil && err != context.Canceled {
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
 	}
 }
 
-func runAgent(ctx context.Context,
+// loadConfig loads the configuration files given on the command line.
+func loadConfig(
 	inputFilters []string,
 	outputFilters []string,
-) error {
-	// Setup default logging. This may need to change after reading the config
-	// file, but we can configure it to use our logger implementation now.
-	logger.SetupLogging(logger.LogConfig{})
-	log.Printf("I! Starting Telegraf %s", version)
-
-	// If no other options are specified, load the config file and run.
+) (*config.Config, error) {
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
 	err := c.LoadConfig(*fConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if *fConfigDirectory != "" {
 		err = c.LoadDirectory(*fConfigDirectory)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
+
+	for _, p := range c.Problems {
+		log.Printf("W! [telegraf] %s", p)
+	}
+
+	if err := validateConfig(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validateConfig checks the loaded configuration can be run.
+func validateConfig(c *config.Config) error {
 	if !*fTest && len(c.Outputs) == 0 {
 		return errors.New("Error: no outputs found, did you provide a valid config file?")
 	}
@@ -150,11 +185,57 @@ func runAgent(ctx context.Context,
 			c.Agent.Interval.Duration)
 	}
 
-	ag, err := agent.NewAgent(c)
+	return nil
+}
+
+// checkConfig loads the configuration files given on the command line in
+// check mode and prints the problems found, it returns false if there are
+// any.
+func checkConfig(inputFilters []string, outputFilters []string) bool {
+	c := config.NewConfig()
+	c.OutputFilters = outputFilters
+	c.InputFilters = inputFilters
+	c.Check = true
+
+	err := c.LoadConfig(*fConfig)
+	if err == nil && *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+	}
+	for _, p := range c.Problems {
+		fmt.Println(p)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+
+	if len(c.Problems) > 0 {
+		return false
+	}
+	if err := validateConfig(c); err != nil {
+		fmt.Println(err)
+		return false
+	}
+	return true
+}
+
+// reloadAgent loads the configuration again and returns the agent to run
+// next.  Plugins whose configuration did not change are taken over from the
+// current agent.
+func reloadAgent(
+	current *agent.Agent,
+	inputFilters []string,
+	outputFilters []string,
+) (*agent.Agent, error) {
+	c, err := loadConfig(inputFilters, outputFilters)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	return current.Reload(c)
+}
+
+func runAgent(ctx context.Context, ag *agent.Agent) error {
 	// Setup logging as configured.
 	logConfig := logger.LogConfig{
 		Debug:               ag.Config.Agent.Debug || *fDebug,
@@ -171,6 +252,7 @@ func runAgent(ctx context.Context,
 		return ag.Test(ctx)
 	}
 
+	c := ag.Config
 	log.Printf("I! Loaded inputs: %s", strings.Join(c.InputNames(), " "))
 	log.Printf("I! Loaded aggregators: %s", strings.Join(c.AggregatorNames(), " "))
 	log.Printf("I! Loaded processors: %s", strings.Join(c.ProcessorNames(), " "))
@@ -299,6 +381,12 @@ func main() {
 			fmt.Println(formatFullVersion())
 			return
 		case "config":
+			if len(args) > 1 && args[1] == "check" {
+				if !checkConfig(inputFilters, outputFilters) {
+					os.Exit(1)
+				}
+				return
+			}
 			config.PrintSampleConfig(
 				sectionFilters,
 				inputFilters,
@@ -0,0 +1,320 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// Problem is a problem found in a configuration file.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// addProblem adds a problem found in the file being loaded, the file is set
+// by LoadConfig.
+func (c *Config) addProblem(line int, format string, args ...interface{}) {
+	p := Problem{Line: line, Message: fmt.Sprintf(format, args...)}
+	for _, other := range c.Problems {
+		if other == p {
+			return
+		}
+	}
+	c.Problems = append(c.Problems, p)
+}
+
+// pluginError handles the error adding the plugin from the table.  In check
+// mode it is added to the problems and nil is returned so that loading
+// continues with the next plugin.
+func (c *Config) pluginError(path string, tbl *ast.Table, err error) error {
+	if err == nil {
+		return nil
+	}
+	if !c.Check {
+		return fmt.Errorf("Error parsing %s, %s", path, err)
+	}
+
+	if lerr, ok := err.(*toml.LineError); ok {
+		if lerr.StructField != "" {
+			c.addProblem(lerr.Line, "(%s) %v", lerr.StructField, lerr.Err)
+		} else {
+			c.addProblem(lerr.Line, "%v", lerr.Err)
+		}
+		return nil
+	}
+	c.addProblem(tbl.Line, "%v", err)
+	return nil
+}
+
+// unmarshalTable is toml.UnmarshalTable, in check mode unknown keys are added
+// to the problems instead of stopping at the first one.
+func (c *Config) unmarshalTable(tbl *ast.Table, v interface{}) error {
+	if !c.Check {
+		return toml.UnmarshalTable(tbl, v)
+	}
+
+	cfg := toml.DefaultConfig
+	cfg.MissingField = func(typ reflect.Type, key string) error {
+		c.addProblem(keyLine(tbl, key), "unknown key %q in %v", key, typ)
+		return nil
+	}
+	return cfg.UnmarshalTable(tbl, v)
+}
+
+// keyLine returns the line of the first key in the table or its sub-tables,
+// or the line of the table if it is not found.
+func keyLine(tbl *ast.Table, key string) int {
+	if node, ok := tbl.Fields[key]; ok {
+		switch node := node.(type) {
+		case *ast.KeyValue:
+			return node.Line
+		case *ast.Table:
+			return node.Line
+		case []*ast.Table:
+			return node[0].Line
+		}
+	}
+
+	keys := make([]string, 0, len(tbl.Fields))
+	for k := range tbl.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var tables []*ast.Table
+		switch node := tbl.Fields[k].(type) {
+		case *ast.Table:
+			tables = []*ast.Table{node}
+		case []*ast.Table:
+			tables = node
+		}
+		for _, t := range tables {
+			if line := keyLine(t, key); line != t.Line {
+				return line
+			}
+		}
+	}
+	return tbl.Line
+}
+
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindInteger
+	kindBoolean
+	kindArray
+	kindTable
+	kindTableArray
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindString:
+		return "string"
+	case kindInteger:
+		return "integer"
+	case kindBoolean:
+		return "boolean"
+	case kindArray:
+		return "array"
+	case kindTable:
+		return "table"
+	case kindTableArray:
+		return "array of tables"
+	}
+	return "unknown"
+}
+
+// formatOption is an option of the data formats.
+type formatOption struct {
+	kind    valueKind
+	formats []string
+}
+
+// parserOptions are the options read by getParserConfig.
+var parserOptions = map[string]formatOption{
+	"separator":                       {kindString, []string{"graphite", "dropwizard"}},
+	"templates":                       {kindArray, []string{"graphite", "dropwizard"}},
+	"tag_keys":                        {kindArray, []string{"json"}},
+	"json_string_fields":              {kindArray, []string{"json"}},
+	"json_name_key":                   {kindString, []string{"json"}},
+	"json_query":                      {kindString, []string{"json"}},
+	"json_time_key":                   {kindString, []string{"json"}},
+	"json_time_format":                {kindString, []string{"json"}},
+	"json_timezone":                   {kindString, []string{"json"}},
+	"data_type":                       {kindString, []string{"value"}},
+	"collectd_auth_file":              {kindString, []string{"collectd"}},
+	"collectd_security_level":         {kindString, []string{"collectd"}},
+	"collectd_parse_multivalue":       {kindString, []string{"collectd"}},
+	"collectd_typesdb":                {kindArray, []string{"collectd"}},
+	"dropwizard_metric_registry_path": {kindString, []string{"dropwizard"}},
+	"dropwizard_time_path":            {kindString, []string{"dropwizard"}},
+	"dropwizard_time_format":          {kindString, []string{"dropwizard"}},
+	"dropwizard_tags_path":            {kindString, []string{"dropwizard"}},
+	"dropwizard_tag_paths":            {kindTable, []string{"dropwizard"}},
+	"grok_named_patterns":             {kindArray, []string{"grok"}},
+	"grok_patterns":                   {kindArray, []string{"grok"}},
+	"grok_custom_patterns":            {kindString, []string{"grok"}},
+	"grok_custom_pattern_files":       {kindArray, []string{"grok"}},
+	"grok_timezone":                   {kindString, []string{"grok"}},
+	"grok_unique_timestamp":           {kindString, []string{"grok"}},
+	"csv_column_names":                {kindArray, []string{"csv"}},
+	"csv_column_types":                {kindArray, []string{"csv"}},
+	"csv_tag_columns":                 {kindArray, []string{"csv"}},
+	"csv_delimiter":                   {kindString, []string{"csv"}},
+	"csv_comment":                     {kindString, []string{"csv"}},
+	"csv_measurement_column":          {kindString, []string{"csv"}},
+	"csv_timestamp_column":            {kindString, []string{"csv"}},
+	"csv_timestamp_format":            {kindString, []string{"csv"}},
+	"csv_header_row_count":            {kindInteger, []string{"csv"}},
+	"csv_skip_rows":                   {kindInteger, []string{"csv"}},
+	"csv_skip_columns":                {kindInteger, []string{"csv"}},
+	"csv_trim_space":                  {kindBoolean, []string{"csv"}},
+	"xml_metric_selection":            {kindString, []string{"xml"}},
+	"xml_metric_name":                 {kindString, []string{"xml"}},
+	"xml_timestamp":                   {kindString, []string{"xml"}},
+	"xml_timestamp_format":            {kindString, []string{"xml"}},
+	"xml_tags":                        {kindTable, []string{"xml"}},
+	"xml_fields":                      {kindTable, []string{"xml"}},
+	"xml_fields_int":                  {kindTable, []string{"xml"}},
+	"json_v2":                         {kindTableArray, []string{"json_v2"}},
+}
+
+// serializerOptions are the options read by buildSerializer.
+var serializerOptions = map[string]formatOption{
+	"prefix":                      {kindString, []string{"graphite", "wavefront"}},
+	"template":                    {kindString, []string{"graphite"}},
+	"influx_max_line_bytes":       {kindInteger, []string{"influx"}},
+	"influx_sort_fields":          {kindBoolean, []string{"influx"}},
+	"influx_uint_support":         {kindBoolean, []string{"influx"}},
+	"graphite_tag_support":        {kindBoolean, []string{"graphite"}},
+	"json_timestamp_units":        {kindString, []string{"json"}},
+	"splunkmetric_hec_routing":    {kindBoolean, []string{"splunkmetric"}},
+	"wavefront_source_override":   {kindArray, []string{"wavefront"}},
+	"wavefront_use_strict":        {kindBoolean, []string{"wavefront"}},
+	"prometheus_export_timestamp": {kindBoolean, []string{"prometheus"}},
+	"prometheus_string_as_label":  {kindBoolean, []string{"prometheus", "prometheusremotewrite"}},
+}
+
+// checkFormatOptions adds problems for the data format options in the table
+// with the wrong type of value or not used by the data format.
+func (c *Config) checkFormatOptions(tbl *ast.Table, options map[string]formatOption, format string) {
+	if node, ok := tbl.Fields["data_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				format = str.Value
+			}
+		}
+	}
+
+	for key, node := range tbl.Fields {
+		opt, ok := options[key]
+		if !ok {
+			continue
+		}
+
+		if !valueIs(node, opt.kind) {
+			c.addProblem(keyLine(tbl, key), "%q must be of type %s", key, opt.kind)
+		}
+
+		if !sliceContains(format, opt.formats) {
+			c.addProblem(keyLine(tbl, key), "%q is not used by data_format %q, only by %s",
+				key, format, strings.Join(opt.formats, ", "))
+		}
+	}
+}
+
+func valueIs(node interface{}, kind valueKind) bool {
+	if kind == kindTable {
+		_, ok := node.(*ast.Table)
+		return ok
+	}
+	if kind == kindTableArray {
+		_, ok := node.([]*ast.Table)
+		return ok
+	}
+
+	kv, ok := node.(*ast.KeyValue)
+	if !ok {
+		return false
+	}
+	switch kv.Value.(type) {
+	case *ast.String:
+		return kind == kindString
+	case *ast.Integer:
+		return kind == kindInteger
+	case *ast.Boolean:
+		return kind == kindBoolean
+	case *ast.Array:
+		return kind == kindArray
+	}
+	return false
+}
+
+// checkFilter adds problems for the values both passed and dropped by the
+// filter in the table.
+func (c *Config) checkFilter(tbl *ast.Table) {
+	pairs := [][2]string{
+		{"namepass", "namedrop"},
+		{"fieldpass", "fielddrop"},
+		{"pass", "drop"},
+		{"fieldpass", "drop"},
+		{"pass", "fielddrop"},
+		{"taginclude", "tagexclude"},
+	}
+	for _, pair := range pairs {
+		pass := stringArray(tbl, pair[0])
+		for _, s := range stringArray(tbl, pair[1]) {
+			if sliceContains(s, pass) {
+				c.addProblem(keyLine(tbl, pair[1]), "%q is in both %s and %s",
+					s, pair[0], pair[1])
+			}
+		}
+	}
+
+	tagpass, ok := tbl.Fields["tagpass"].(*ast.Table)
+	if !ok {
+		return
+	}
+	tagdrop, ok := tbl.Fields["tagdrop"].(*ast.Table)
+	if !ok {
+		return
+	}
+	for key := range tagdrop.Fields {
+		pass := stringArray(tagpass, key)
+		for _, s := range stringArray(tagdrop, key) {
+			if sliceContains(s, pass) {
+				c.addProblem(keyLine(tagdrop, key), "tag %s = %q is in both tagpass and tagdrop",
+					key, s)
+			}
+		}
+	}
+}
+
+// stringArray returns the strings of the array with the key in the table.
+func stringArray(tbl *ast.Table, key string) []string {
+	var values []string
+	if node, ok := tbl.Fields[key]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						values = append(values, str.Value)
+					}
+				}
+			}
+		}
+	}
+	return values
+}
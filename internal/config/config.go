@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
@@ -25,6 +27,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
 	"github.com/influxdata/telegraf/plugins/processors"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/toml"
@@ -66,6 +69,15 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+	// PostProcessors are the processors of the post aggregation stage.
+	PostProcessors models.RunningProcessors
+
+	// Check enables check mode, errors in the plugins are added to Problems
+	// instead of stopping the loading of the configuration, and all unknown
+	// keys are reported.
+	Check bool
+	// Problems are the problems found while loading the configuration.
+	Problems []Problem
 }
 
 func NewConfig() *Config {
@@ -78,12 +90,13 @@ func NewConfig() *Config {
 			LogfileRotationMaxArchives: 5,
 		},
 
-		Tags:          make(map[string]string),
-		Inputs:        make([]*models.RunningInput, 0),
-		Outputs:       make([]*models.RunningOutput, 0),
-		Processors:    make([]*models.RunningProcessor, 0),
-		InputFilters:  make([]string, 0),
-		OutputFilters: make([]string, 0),
+		Tags:           make(map[string]string),
+		Inputs:         make([]*models.RunningInput, 0),
+		Outputs:        make([]*models.RunningOutput, 0),
+		Processors:     make([]*models.RunningProcessor, 0),
+		PostProcessors: make([]*models.RunningProcessor, 0),
+		InputFilters:   make([]string, 0),
+		OutputFilters:  make([]string, 0),
 	}
 	return c
 }
@@ -163,6 +176,10 @@ type AgentConfig struct {
 
 	Hostname     string
 	OmitHostname bool
+
+	// APIAddress is the address of the management API, the empty string
+	// disables the API.
+	APIAddress string `toml:"api_address"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -189,6 +206,9 @@ func (c *Config) ProcessorNames() []string {
 	for _, processor := range c.Processors {
 		name = append(name, processor.Name)
 	}
+	for _, processor := range c.PostProcessors {
+		name = append(name, processor.Name)
+	}
 	return name
 }
 
@@ -305,6 +325,9 @@ var agentConfig = `
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Address of the management API, the API is disabled when empty.
+  # api_address = "localhost:8099"
+
 `
 
 var outputHeader = `
@@ -667,6 +690,17 @@ func (c *Config) LoadConfig(path string) error {
 		return fmt.Errorf("Error loading %s, %s", path, err)
 	}
 
+	n := len(c.Problems)
+	defer func() {
+		problems := c.Problems[n:]
+		for i := range problems {
+			problems[i].File = path
+		}
+		sort.SliceStable(problems, func(i, j int) bool {
+			return problems[i].Line < problems[j].Line
+		})
+	}()
+
 	tbl, err := parseConfig(data)
 	if err != nil {
 		return fmt.Errorf("Error parsing %s, %s", path, err)
@@ -679,9 +713,11 @@ func (c *Config) LoadConfig(path string) error {
 			if !ok {
 				return fmt.Errorf("%s: invalid configuration", path)
 			}
-			if err = toml.UnmarshalTable(subTable, c.Tags); err != nil {
+			if err = c.unmarshalTable(subTable, c.Tags); err != nil {
 				log.Printf("E! Could not parse [global_tags] config\n")
-				return fmt.Errorf("Error parsing %s, %s", path, err)
+				if err = c.pluginError(path, subTable, err); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -692,9 +728,11 @@ func (c *Config) LoadConfig(path string) error {
 		if !ok {
 			return fmt.Errorf("%s: invalid configuration", path)
 		}
-		if err = toml.UnmarshalTable(subTable, c.Agent); err != nil {
+		if err = c.unmarshalTable(subTable, c.Agent); err != nil {
 			log.Printf("E! Could not parse [agent] config\n")
-			return fmt.Errorf("Error parsing %s, %s", path, err)
+			if err = c.pluginError(path, subTable, err); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -725,13 +763,15 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				// legacy [outputs.influxdb] support
 				case *ast.Table:
-					if err = c.addOutput(pluginName, pluginSubTable); err != nil {
-						return fmt.Errorf("Error parsing %s, %s", path, err)
+					err = c.pluginError(path, pluginSubTable, c.addOutput(pluginName, pluginSubTable))
+					if err != nil {
+						return err
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addOutput(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+						err = c.pluginError(path, t, c.addOutput(pluginName, t))
+						if err != nil {
+							return err
 						}
 					}
 				default:
@@ -744,13 +784,15 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				// legacy [inputs.cpu] support
 				case *ast.Table:
-					if err = c.addInput(pluginName, pluginSubTable); err != nil {
-						return fmt.Errorf("Error parsing %s, %s", path, err)
+					err = c.pluginError(path, pluginSubTable, c.addInput(pluginName, pluginSubTable))
+					if err != nil {
+						return err
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addInput(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+						err = c.pluginError(path, t, c.addInput(pluginName, t))
+						if err != nil {
+							return err
 						}
 					}
 				default:
@@ -763,8 +805,9 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addProcessor(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+						err = c.pluginError(path, t, c.addProcessor(pluginName, t))
+						if err != nil {
+							return err
 						}
 					}
 				default:
@@ -777,8 +820,9 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addAggregator(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+						err = c.pluginError(path, t, c.addAggregator(pluginName, t))
+						if err != nil {
+							return err
 						}
 					}
 				default:
@@ -789,14 +833,18 @@ func (c *Config) LoadConfig(path string) error {
 		// Assume it's an input input for legacy config file support if no other
 		// identifiers are present
 		default:
-			if err = c.addInput(name, subTable); err != nil {
-				return fmt.Errorf("Error parsing %s, %s", path, err)
+			err = c.pluginError(path, subTable, c.addInput(name, subTable))
+			if err != nil {
+				return err
 			}
 		}
 	}
 
 	if len(c.Processors) > 1 {
-		sort.Sort(c.Processors)
+		sort.Stable(c.Processors)
+	}
+	if len(c.PostProcessors) > 1 {
+		sort.Stable(c.PostProcessors)
 	}
 
 	return nil
@@ -889,17 +937,21 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested aggregator: %s", name)
 	}
 	aggregator := creator()
+	hash := tableHash(name, table)
 
+	c.checkFilter(table)
 	conf, err := buildAggregator(name, table)
 	if err != nil {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, aggregator); err != nil {
+	if err := c.unmarshalTable(table, aggregator); err != nil {
 		return err
 	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	ra := models.NewRunningAggregator(aggregator, conf)
+	ra.Hash = hash
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
@@ -909,13 +961,18 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested processor: %s", name)
 	}
 	processor := creator()
+	hash := tableHash(name, table)
 
+	c.checkFilter(table)
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, processor); err != nil {
+	if err := c.unmarshalTable(table, processor); err != nil {
+		return err
+	}
+	if err := initProcessor(name, processor); err != nil {
 		return err
 	}
 
@@ -923,9 +980,46 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		Name:      name,
 		Processor: processor,
 		Config:    processorConfig,
+		Hash:      hash,
 	}
 
-	c.Processors = append(c.Processors, rf)
+	if processorConfig.Workers > 1 {
+		if _, ok := processor.(processors.SingleWorker); ok {
+			return fmt.Errorf("processor %s does not support more than one worker", name)
+		}
+		rf.Workers = append(rf.Workers, rf)
+		for i := 1; i < processorConfig.Workers; i++ {
+			processor := creator()
+			if err := c.unmarshalTable(table, processor); err != nil {
+				return err
+			}
+			if err := initProcessor(name, processor); err != nil {
+				return err
+			}
+			rf.Workers = append(rf.Workers, &models.RunningProcessor{
+				Name:      name,
+				Processor: processor,
+				Config:    processorConfig,
+			})
+		}
+	}
+
+	if processorConfig.Stage == models.StagePostAggregation {
+		c.PostProcessors = append(c.PostProcessors, rf)
+	} else {
+		c.Processors = append(c.Processors, rf)
+	}
+	return nil
+}
+
+// initProcessor checks the settings of the processor if it is an
+// Initializer.
+func initProcessor(name string, processor telegraf.Processor) error {
+	if p, ok := processor.(processors.Initializer); ok {
+		if err := p.Init(); err != nil {
+			return fmt.Errorf("processor %s: %v", name, err)
+		}
+	}
 	return nil
 }
 
@@ -938,11 +1032,13 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested output: %s", name)
 	}
 	output := creator()
+	hash := tableHash(name, table)
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
 	switch t := output.(type) {
 	case serializers.SerializerOutput:
+		c.checkFormatOptions(table, serializerOptions, "influx")
 		serializer, err := buildSerializer(name, table)
 		if err != nil {
 			return err
@@ -950,17 +1046,28 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		t.SetSerializer(serializer)
 	}
 
+	c.checkFilter(table)
 	outputConfig, err := buildOutput(name, table)
 	if err != nil {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, output); err != nil {
+	if outputConfig.BufferPath != "" {
+		for _, ro := range c.Outputs {
+			if ro.Config.BufferPath == outputConfig.BufferPath {
+				return fmt.Errorf("buffer_path %q is used by more than one output",
+					outputConfig.BufferPath)
+			}
+		}
+	}
+
+	if err := c.unmarshalTable(table, output); err != nil {
 		return err
 	}
 
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
+	ro.Hash = hash
 	c.Outputs = append(c.Outputs, ro)
 	return nil
 }
@@ -979,6 +1086,17 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested input: %s", name)
 	}
 	input := creator()
+	hash := tableHash(name, table)
+
+	switch input.(type) {
+	case parsers.ParserInput, parsers.ParserFuncInput:
+		// Legacy support, exec plugin originally parsed JSON by default.
+		if name == "exec" {
+			c.checkFormatOptions(table, parserOptions, "json")
+		} else {
+			c.checkFormatOptions(table, parserOptions, "influx")
+		}
+	}
 
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
@@ -1002,21 +1120,77 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		})
 	}
 
+	c.checkFilter(table)
 	pluginConfig, err := buildInput(name, table)
 	if err != nil {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, input); err != nil {
+	if err := c.unmarshalTable(table, input); err != nil {
 		return err
 	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
+	rp.Hash = hash
 	rp.SetDefaultTags(c.Tags)
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
 
+// tableHash returns a hash of the plugin name and its configuration table.
+// Tables with the same keys and values hash equally regardless of their
+// formatting or the order of the keys.
+func tableHash(name string, tbl *ast.Table) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	h.Write([]byte("\n"))
+	writeTable(h, tbl)
+	return h.Sum64()
+}
+
+func writeTable(w io.Writer, tbl *ast.Table) {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s=", key)
+		switch v := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			writeValue(w, v.Value)
+		case *ast.Table:
+			fmt.Fprint(w, "{")
+			writeTable(w, v)
+			fmt.Fprint(w, "}")
+		case []*ast.Table:
+			for _, t := range v {
+				fmt.Fprint(w, "[{")
+				writeTable(w, t)
+				fmt.Fprint(w, "}]")
+			}
+		}
+		fmt.Fprint(w, "\n")
+	}
+}
+
+func writeValue(w io.Writer, value ast.Value) {
+	switch v := value.(type) {
+	case *ast.String:
+		fmt.Fprintf(w, "%q", v.Value)
+	case *ast.Array:
+		fmt.Fprint(w, "[")
+		for _, elem := range v.Value {
+			writeValue(w, elem)
+			fmt.Fprint(w, ",")
+		}
+		fmt.Fprint(w, "]")
+	default:
+		fmt.Fprint(w, value.Source())
+	}
+}
+
 // buildAggregator parses Aggregator specific items from the ast.Table,
 // builds the filter and returns a
 // models.AggregatorConfig to be inserted into models.RunningAggregator
@@ -1131,7 +1305,38 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 		}
 	}
 
+	if node, ok := tbl.Fields["workers"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				if v < 0 {
+					return nil, fmt.Errorf("invalid workers %d, must not be negative", v)
+				}
+				conf.Workers = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["stage"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				switch str.Value {
+				case models.StagePreAggregation, models.StagePostAggregation:
+					conf.Stage = str.Value
+				default:
+					return nil, fmt.Errorf("invalid stage %q, must be %q or %q",
+						str.Value, models.StagePreAggregation, models.StagePostAggregation)
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "order")
+	delete(tbl.Fields, "workers")
+	delete(tbl.Fields, "stage")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1260,6 +1465,15 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 			}
 		}
 	}
+
+	if node, ok := tbl.Fields["metricpass"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				f.MetricPass = str.Value
+			}
+		}
+	}
+
 	if err := f.Compile(); err != nil {
 		return f, err
 	}
@@ -1274,6 +1488,7 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 	delete(tbl.Fields, "tagpass")
 	delete(tbl.Fields, "tagexclude")
 	delete(tbl.Fields, "taginclude")
+	delete(tbl.Fields, "metricpass")
 	return f, nil
 }
 
@@ -1328,11 +1543,20 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["route"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Route = str.Value
+			}
+		}
+	}
+
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "interval")
 	delete(tbl.Fields, "tags")
+	delete(tbl.Fields, "route")
 	var err error
 	cp.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1726,6 +1950,91 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	//for xml data_format
+	if node, ok := tbl.Fields["xml_metric_selection"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.XMLMetricSelection = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml_metric_name"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.XMLMetricName = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml_timestamp"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.XMLTimestamp = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.XMLTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml_tags"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.XMLTags = make(map[string]string)
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.XMLTags[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml_fields"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.XMLFields = make(map[string]string)
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.XMLFields[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml_fields_int"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.XMLFieldsInt = make(map[string]string)
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.XMLFieldsInt[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
+	//for json_v2 data_format
+	if node, ok := tbl.Fields["json_v2"]; ok {
+		if subtbls, ok := node.([]*ast.Table); ok {
+			for _, subtbl := range subtbls {
+				var cfg json_v2.Config
+				if err := toml.UnmarshalTable(subtbl, &cfg); err != nil {
+					return nil, err
+				}
+				c.JSONV2Config = append(c.JSONV2Config, cfg)
+			}
+		}
+	}
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
@@ -1767,6 +2076,14 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "csv_timestamp_column")
 	delete(tbl.Fields, "csv_timestamp_format")
 	delete(tbl.Fields, "csv_trim_space")
+	delete(tbl.Fields, "xml_metric_selection")
+	delete(tbl.Fields, "xml_metric_name")
+	delete(tbl.Fields, "xml_timestamp")
+	delete(tbl.Fields, "xml_timestamp_format")
+	delete(tbl.Fields, "xml_tags")
+	delete(tbl.Fields, "xml_fields")
+	delete(tbl.Fields, "xml_fields_int")
+	delete(tbl.Fields, "json_v2")
 
 	return c, nil
 }
@@ -1905,6 +2222,30 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["prometheus_export_timestamp"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.PrometheusExportTimestamp, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["prometheus_string_as_label"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.PrometheusStringAsLabel, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "influx_max_line_bytes")
 	delete(tbl.Fields, "influx_sort_fields")
 	delete(tbl.Fields, "influx_uint_support")
@@ -1916,6 +2257,8 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 	delete(tbl.Fields, "splunkmetric_hec_routing")
 	delete(tbl.Fields, "wavefront_source_override")
 	delete(tbl.Fields, "wavefront_use_strict")
+	delete(tbl.Fields, "prometheus_export_timestamp")
+	delete(tbl.Fields, "prometheus_string_as_label")
 	return serializers.NewSerializer(c)
 }
 
@@ -1979,9 +2322,129 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["buffer_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferPath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_max_size"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size internal.Size
+			if err := size.UnmarshalTOML([]byte(kv.Value.Source())); err != nil {
+				return nil, fmt.Errorf("invalid buffer_max_size: %v", err)
+			}
+			oc.BufferMaxSize = size.Size
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_fsync"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				switch str.Value {
+				case models.FsyncAlways, models.FsyncFlush, models.FsyncNever:
+					oc.BufferFsync = str.Value
+				default:
+					return nil, fmt.Errorf("invalid buffer_fsync %q, must be one of %q, %q or %q",
+						str.Value, models.FsyncAlways, models.FsyncFlush, models.FsyncNever)
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_max"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.RetryMax = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				oc.RetryInterval = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_max_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				oc.RetryMaxInterval = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["circuit_breaker_threshold"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.CircuitBreakerThreshold = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["circuit_breaker_timeout"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				oc.CircuitBreakerTimeout = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["routes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						oc.Routes = append(oc.Routes, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if err := oc.CompileRoutes(); err != nil {
+		return nil, fmt.Errorf("invalid routes: %v", err)
+	}
+
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "metric_buffer_limit")
 	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "buffer_path")
+	delete(tbl.Fields, "buffer_max_size")
+	delete(tbl.Fields, "buffer_fsync")
+	delete(tbl.Fields, "retry_max")
+	delete(tbl.Fields, "retry_interval")
+	delete(tbl.Fields, "retry_max_interval")
+	delete(tbl.Fields, "circuit_breaker_threshold")
+	delete(tbl.Fields, "circuit_breaker_timeout")
+	delete(tbl.Fields, "routes")
 
 	return oc, nil
 }
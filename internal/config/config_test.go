@@ -14,6 +14,10 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
 	httpOut "github.com/influxdata/telegraf/plugins/outputs/http"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	_ "github.com/influxdata/telegraf/plugins/processors/cardinality"
+	_ "github.com/influxdata/telegraf/plugins/processors/math"
+	_ "github.com/influxdata/telegraf/plugins/processors/rename"
+	_ "github.com/influxdata/telegraf/plugins/processors/topk"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -251,3 +255,70 @@ func TestConfig_BadOrdering(t *testing.T) {
 	require.Error(t, err, "bad ordering")
 	assert.Equal(t, "Error parsing ./testdata/non_slice_slice.toml, line 4: cannot unmarshal TOML array into string (need slice)", err.Error())
 }
+
+func TestConfig_ProcessorStages(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/processor_stages.toml")
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(c.Processors))
+	require.Equal(t, int64(1), c.Processors[0].Config.Order)
+	require.Equal(t, models.StagePreAggregation, c.Processors[0].Config.Stage)
+	require.Equal(t, int64(2), c.Processors[1].Config.Order)
+	require.Equal(t, "", c.Processors[1].Config.Stage)
+
+	require.Equal(t, 1, len(c.PostProcessors))
+	require.Equal(t, models.StagePostAggregation, c.PostProcessors[0].Config.Stage)
+
+	c = NewConfig()
+	err = c.LoadConfig("./testdata/invalid_stage.toml")
+	require.Error(t, err)
+}
+
+func TestConfig_SingleWorkerProcessor(t *testing.T) {
+	for _, file := range []string{
+		"./testdata/single_worker_processor.toml",
+		"./testdata/single_worker_topk.toml",
+	} {
+		c := NewConfig()
+		err := c.LoadConfig(file)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not support more than one worker")
+	}
+}
+
+func TestConfig_InvalidProcessor(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/invalid_processor.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "processor math")
+}
+
+func TestConfig_Check(t *testing.T) {
+	c := NewConfig()
+	c.Check = true
+	err := c.LoadConfig("./testdata/check.toml")
+	require.NoError(t, err)
+
+	file := "./testdata/check.toml"
+	expected := []Problem{
+		{file, 3, "unknown key \"not_an_agent_option\" in config.AgentConfig"},
+		{file, 7, "unknown key \"not_a_field\" in memcached.Memcached"},
+		{file, 9, "\"mem\" is in both namepass and namedrop"},
+		{file, 14, "\"json_query\" is not used by data_format \"influx\", only by json"},
+		{file, 15, "\"csv_skip_rows\" must be of type integer"},
+		{file, 15, "\"csv_skip_rows\" is not used by data_format \"influx\", only by csv"},
+		{file, 17, "Undefined but requested input: undefined_plugin"},
+		{file, 20, "(http_listener_v2.HTTPListenerV2.Port) cannot unmarshal TOML string into int"},
+		{file, 25, "\"influx_sort_fields\" is not used by data_format \"json\", only by influx"},
+		{file, 29, "tag host = \"a\" is in both tagpass and tagdrop"},
+	}
+	require.Equal(t, expected, c.Problems)
+	require.Equal(t, 2, len(c.Inputs))
+	require.Equal(t, 1, len(c.Outputs))
+
+	// without check mode loading stops at the first error
+	c = NewConfig()
+	err = c.LoadConfig("./testdata/check.toml")
+	require.Error(t, err)
+}
@@ -0,0 +1,453 @@
+// Package expr implements expressions evaluated against a metric, such as
+//
+//	fields.usage_idle < 10 && tags.host =~ /^web/
+//
+// An expression refers to the metric using name, fields.<key> and
+// tags.<key>; keys with spaces or operators are written as fields["<key>"].
+// Values are compared with ==, !=, <, <=, > and >=, strings are matched
+// against regular expressions with =~ and !~, and conditions are combined
+// with &&, || and !.  A field or tag missing from the metric has no value:
+// comparing it is false, except for != which is true.
+//
+// Numbers are computed with +, -, *, / and %, such as
+//
+//	fields.used / fields.total * 100
+//
+// A fields.<key> or tags.<key> ends at an operator, keys containing one are
+// written in brackets.
+//
+// Integers stay integers, except with / which always gives a float, and are
+// converted to floats when mixed with them.  Strings, such as tags, are
+// converted to numbers.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Expression is a compiled expression.
+type Expression struct {
+	src  string
+	root node
+}
+
+// Compile parses the expression s.
+func Compile(s string) (*Expression, error) {
+	root, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{src: s, root: root}, nil
+}
+
+// Eval returns true if the expression is true for the metric.
+func (e *Expression) Eval(m telegraf.Metric) bool {
+	return truthy(e.root.eval(m))
+}
+
+// Value returns the value of the expression for the metric.  It returns an
+// error if the expression has no value, such as when a field it uses is
+// missing from the metric.
+func (e *Expression) Value(m telegraf.Metric) (interface{}, error) {
+	switch v := e.root.eval(m).(type) {
+	case nil:
+		return nil, missing(e.root)
+	case error:
+		return nil, v
+	default:
+		return v, nil
+	}
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.src
+}
+
+// node is a node of the expression tree, eval returns nil or a bool, int64,
+// uint64, float64 or string.  Arithmetic returns an error instead of a value
+// when it cannot be computed, which is handled as no value.
+type node interface {
+	eval(m telegraf.Metric) interface{}
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n *literal) eval(m telegraf.Metric) interface{} {
+	return n.value
+}
+
+type nameRef struct{}
+
+func (n *nameRef) eval(m telegraf.Metric) interface{} {
+	return m.Name()
+}
+
+type fieldRef struct {
+	key string
+}
+
+func (n *fieldRef) eval(m telegraf.Metric) interface{} {
+	v, ok := m.GetField(n.key)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+type tagRef struct {
+	key string
+}
+
+func (n *tagRef) eval(m telegraf.Metric) interface{} {
+	v, ok := m.GetTag(n.key)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+type notNode struct {
+	x node
+}
+
+func (n *notNode) eval(m telegraf.Metric) interface{} {
+	return !truthy(n.x.eval(m))
+}
+
+type andNode struct {
+	l, r node
+}
+
+func (n *andNode) eval(m telegraf.Metric) interface{} {
+	return truthy(n.l.eval(m)) && truthy(n.r.eval(m))
+}
+
+type orNode struct {
+	l, r node
+}
+
+func (n *orNode) eval(m telegraf.Metric) interface{} {
+	return truthy(n.l.eval(m)) || truthy(n.r.eval(m))
+}
+
+type matchNode struct {
+	x      node
+	re     *regexp.Regexp
+	negate bool
+}
+
+func (n *matchNode) eval(m telegraf.Metric) interface{} {
+	s, ok := n.x.eval(m).(string)
+	if !ok {
+		return false
+	}
+	return n.re.MatchString(s) != n.negate
+}
+
+type compareNode struct {
+	op   string
+	l, r node
+}
+
+func (n *compareNode) eval(m telegraf.Metric) interface{} {
+	l, r := n.l.eval(m), n.r.eval(m)
+	c, ok := compare(l, r)
+	if !ok {
+		return n.op == "!="
+	}
+
+	switch n.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// compare returns -1, 0 or 1 if l is less than, equal to or greater than r,
+// ok is false if the values cannot be compared.
+func compare(l, r interface{}) (int, bool) {
+	if !hasValue(l) || !hasValue(r) {
+		return 0, false
+	}
+
+	switch l := l.(type) {
+	case string:
+		r, ok := r.(string)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case l < r:
+			return -1, true
+		case l > r:
+			return 1, true
+		}
+		return 0, true
+	case bool:
+		r, ok := r.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case l == r:
+			return 0, true
+		case r:
+			return -1, true
+		}
+		return 1, true
+	}
+
+	if l, ok := l.(int64); ok {
+		if r, ok := r.(int64); ok {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+
+	lf, ok := toFloat(l)
+	if !ok {
+		return 0, false
+	}
+	rf, ok := toFloat(r)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case lf < rf:
+		return -1, true
+	case lf > rf:
+		return 1, true
+	case lf == rf:
+		return 0, true
+	}
+	// NaN
+	return 0, false
+}
+
+var errDivisionByZero = errors.New("division by zero")
+
+type arithNode struct {
+	op   string
+	l, r node
+}
+
+func (n *arithNode) eval(m telegraf.Metric) interface{} {
+	l, err := number(n.l, m)
+	if err != nil {
+		return err
+	}
+	r, err := number(n.r, m)
+	if err != nil {
+		return err
+	}
+
+	if n.op == "/" {
+		lf, _ := toFloat(l)
+		rf, _ := toFloat(r)
+		if rf == 0 {
+			return errDivisionByZero
+		}
+		return lf / rf
+	}
+
+	li, lint := l.(int64)
+	ri, rint := r.(int64)
+	lu, luint := l.(uint64)
+	ru, ruint := r.(uint64)
+	switch {
+	case lint && rint:
+		return intArith(n.op, li, ri)
+	case luint && ruint:
+		return uintArith(n.op, lu, ru)
+	case lint && ruint && ru <= math.MaxInt64:
+		return intArith(n.op, li, int64(ru))
+	case lint && ruint && li >= 0:
+		return uintArith(n.op, uint64(li), ru)
+	case luint && rint && lu <= math.MaxInt64:
+		return intArith(n.op, int64(lu), ri)
+	case luint && rint && ri >= 0:
+		return uintArith(n.op, lu, uint64(ri))
+	}
+
+	lf, _ := toFloat(l)
+	rf, _ := toFloat(r)
+	switch n.op {
+	case "+":
+		return lf + rf
+	case "-":
+		return lf - rf
+	case "*":
+		return lf * rf
+	case "%":
+		if rf == 0 {
+			return errDivisionByZero
+		}
+		return math.Mod(lf, rf)
+	}
+	return fmt.Errorf("unknown operator %q", n.op)
+}
+
+func intArith(op string, l, r int64) interface{} {
+	switch op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "%":
+		if r == 0 {
+			return errDivisionByZero
+		}
+		return l % r
+	}
+	return fmt.Errorf("unknown operator %q", op)
+}
+
+func uintArith(op string, l, r uint64) interface{} {
+	switch op {
+	case "+":
+		return l + r
+	case "-":
+		if l >= r {
+			return l - r
+		}
+		// The result is negative.
+		if r-l <= math.MaxInt64 {
+			return -int64(r - l)
+		}
+		return float64(l) - float64(r)
+	case "*":
+		return l * r
+	case "%":
+		if r == 0 {
+			return errDivisionByZero
+		}
+		return l % r
+	}
+	return fmt.Errorf("unknown operator %q", op)
+}
+
+type negNode struct {
+	x node
+}
+
+func (n *negNode) eval(m telegraf.Metric) interface{} {
+	x, err := number(n.x, m)
+	if err != nil {
+		return err
+	}
+	switch x := x.(type) {
+	case int64:
+		return -x
+	case uint64:
+		if x <= math.MaxInt64 {
+			return -int64(x)
+		}
+		return -float64(x)
+	case float64:
+		return -x
+	}
+	return nil
+}
+
+// number evaluates the node to an int64, uint64 or float64, strings are
+// parsed as numbers.
+func number(n node, m telegraf.Metric) (interface{}, error) {
+	switch v := n.eval(m).(type) {
+	case nil:
+		return nil, missing(n)
+	case error:
+		return nil, v
+	case int64, uint64, float64:
+		return v, nil
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i, nil
+		}
+		if u, err := strconv.ParseUint(v, 10, 64); err == nil {
+			return u, nil
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, nil
+		}
+		return nil, fmt.Errorf("%q is not a number", v)
+	default:
+		return nil, fmt.Errorf("%v is not a number", v)
+	}
+}
+
+// missing returns the error for the node without value.
+func missing(n node) error {
+	switch n := n.(type) {
+	case *fieldRef:
+		return fmt.Errorf("field %q is missing", n.key)
+	case *tagRef:
+		return fmt.Errorf("tag %q is missing", n.key)
+	}
+	return errors.New("expression has no value")
+}
+
+// hasValue returns false for no value and errors.
+func hasValue(v interface{}) bool {
+	switch v.(type) {
+	case nil, error:
+		return false
+	}
+	return true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// truthy returns false for no value, false, zero and the empty string.
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil, error:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case int64:
+		return v != 0
+	case uint64:
+		return v != 0
+	case float64:
+		return v != 0
+	}
+	return true
+}
@@ -0,0 +1,209 @@
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetric(t *testing.T) telegraf.Metric {
+	m, err := metric.New("cpu",
+		map[string]string{
+			"host": "web01",
+			"cpu":  "cpu-total",
+			"dir":  "/var/log",
+			"a+b":  "c*d",
+		},
+		map[string]interface{}{
+			"usage_idle": 5.5,
+			"count":      int64(42),
+			"big":        uint64(1 << 63),
+			"status":     "ok",
+			"active":     true,
+			"bytes-sent": int64(10),
+			"rx/s":       int64(3),
+			"cpu%":       12.5,
+		},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, err)
+	return m
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`fields.usage_idle < 10 && tags.host =~ /^web/`, true},
+		{`fields.usage_idle < 5`, false},
+		{`fields.usage_idle >= 5.5`, true},
+		{`fields.count == 42`, true},
+		{`fields.count != 42`, false},
+		{`fields.count > 41.5`, true},
+		{`fields.big > 42`, true},
+		{`fields.status == "ok"`, true},
+		{`fields.status == 'ok'`, true},
+		{`fields.status < "pk"`, true},
+		{`fields.active`, true},
+		{`fields.active == false`, false},
+		{`!fields.active`, false},
+		{`fields["bytes-sent"] == 10`, true},
+		{`tags.cpu =~ "^cpu-"`, true},
+		{`tags.cpu !~ /total$/`, false},
+		{`tags.path =~ /\/var/`, false},
+		{`name == "cpu"`, true},
+		{`name == "mem" || (tags.host == "web01" && fields.count > 0)`, true},
+		{`name == "mem" || tags.host == "web02" && fields.count > 0`, false},
+		{`!(name == "mem")`, true},
+		{`fields.count > -1`, true},
+
+		// keys end at an operator
+		{`fields.count*2 == 84`, true},
+		{`fields.count-2 == 40`, true},
+		{`fields.count/fields.count>0.5`, true},
+		{`fields["rx/s"] == 3`, true},
+		{`fields["cpu%"] > 10`, true},
+		{`tags["a+b"] == "c*d"`, true},
+		{`tags.dir =~ /^\/var/`, true},
+		{`tags.dir=~/log$/`, true},
+
+		// missing values
+		{`fields.missing`, false},
+		{`fields.missing == 0`, false},
+		{`fields.missing < 1`, false},
+		{`fields.missing != 0`, true},
+		{`tags.missing =~ /.*/`, false},
+
+		// arithmetic
+		{`fields.count * 2 == 84`, true},
+		{`fields.count / 4 > 10`, true},
+		{`fields.count - 50 < 0`, true},
+		{`fields.missing + 1 == 1`, false},
+		{`fields.missing + 1 != 1`, true},
+		{`fields.count / 0 > 0`, false},
+
+		// mismatched types
+		{`fields.status == 1`, false},
+		{`fields.count == "42"`, false},
+		{`fields.count =~ /42/`, false},
+	}
+	m := testMetric(t)
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Compile(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, e.Eval(m))
+		})
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	tests := []string{
+		``,
+		`fields`,
+		`fields.`,
+		`fields[usage]`,
+		`fields["usage"`,
+		`host == "a"`,
+		`name == `,
+		`name == "a`,
+		`name =~ /a`,
+		`name =~ /[/`,
+		`name =~ fields.pattern`,
+		`(name == "a"`,
+		`name == "a")`,
+		`name == "a" &&`,
+		`name = "a"`,
+		`1.2.3 == 1`,
+		`fields.count +`,
+		`fields.count * / 2`,
+		`fields.count / /2/`,
+		`fields.bytes-sent == 10`,
+		`fields.rx/s > 2`,
+		`fields.cpu% > 10`,
+		`tags.a+b == "c*d"`,
+	}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			_, err := Compile(tt)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCompileErrorKeyWithOperator(t *testing.T) {
+	_, err := Compile(`fields.bytes-sent == 10`)
+	require.EqualError(t, err, `invalid expression "fields.bytes-sent == 10" at position 14: `+
+		`unknown identifier "sent", write a key containing operators as fields["bytes-sent"]`)
+}
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected interface{}
+	}{
+		{`fields.count + 1`, int64(43)},
+		{`fields.count - 50`, int64(-8)},
+		{`fields.count * 2 + 1`, int64(85)},
+		{`fields.count * (2 + 1)`, int64(126)},
+		{`fields.count % 5`, int64(2)},
+		{`fields.count / 4`, 10.5},
+		{`fields.count / 42 * 100`, 100.0},
+		{`fields.usage_idle * 2`, 11.0},
+		{`fields.usage_idle + fields.count`, 47.5},
+		{`fields.usage_idle % 2`, 1.5},
+		{`-fields.count`, int64(-42)},
+		{`2 - -fields.count`, int64(44)},
+		{`fields.big + fields.big / 2`, 1.5 * (1 << 63)},
+		{`fields.big - 1`, uint64(1<<63 - 1)},
+		{`fields.big - fields.big - 1`, int64(-1)},
+		{`fields.count - fields.big`, int64(-9223372036854775766)},
+		{`fields.count + tags.cores`, int64(46)},
+		{`fields["bytes-sent"]*8`, int64(80)},
+		{`fields["rx/s"]*8`, int64(24)},
+		{`fields.count/fields.count*100`, 100.0},
+		{`fields.count-fields.count`, int64(0)},
+		{`-9223372036854775808`, int64(-9223372036854775808)},
+		{`fields.count > 40`, true},
+		{`tags.host`, "web01"},
+	}
+	m := testMetric(t)
+	m.AddTag("cores", "4")
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Compile(tt.expr)
+			require.NoError(t, err)
+			v, err := e.Value(m)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, v)
+		})
+	}
+}
+
+func TestValueError(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{`fields.missing`, `field "missing" is missing`},
+		{`fields.count + fields.missing`, `field "missing" is missing`},
+		{`tags.missing * 2`, `tag "missing" is missing`},
+		{`fields.count / 0`, `division by zero`},
+		{`fields.count % 0`, `division by zero`},
+		{`fields.status + 1`, `"ok" is not a number`},
+		{`fields.active + 1`, `true is not a number`},
+	}
+	m := testMetric(t)
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Compile(tt.expr)
+			require.NoError(t, err)
+			_, err = e.Value(m)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,474 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenKey
+	tokenNumber
+	tokenString
+	tokenRegex
+	tokenOp
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// parser is a recursive descent parser, from lowest to highest precedence:
+//
+//	or      = and { "||" and }
+//	and     = not { "&&" not }
+//	not     = "!" not | compare
+//	compare = sum [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) sum ]
+//	        | sum ( "=~" | "!~" ) ( regex | string )
+//	sum     = product { ( "+" | "-" ) product }
+//	product = unary { ( "*" | "/" | "%" ) unary }
+//	unary   = "-" unary | operand
+//	operand = number | string | "true" | "false" | "name"
+//	        | ( "fields" | "tags" ) ( "." key | "[" string "]" )
+//	        | "(" or ")"
+type parser struct {
+	src string
+	pos int
+	tok token
+
+	// keyStart and keyEnd are the positions of the last key read by
+	// nextKey.
+	keyStart, keyEnd int
+}
+
+func parse(s string) (node, error) {
+	p := &parser{src: s}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenEOF {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %q", p.tok.value)
+	}
+	return n, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid expression %q at position %d: %s",
+		p.src, p.tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) isOp(op string) bool {
+	return p.tok.kind == tokenOp && p.tok.value == op
+}
+
+func (p *parser) parseOr() (node, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &orNode{l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &andNode{l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isOp("!") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{x: x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	l, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenOp {
+		return l, nil
+	}
+
+	op := p.tok.value
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		r, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{op: op, l: l, r: r}, nil
+	case "=~", "!~":
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenRegex && p.tok.kind != tokenString {
+			return nil, p.errorf("expected regular expression")
+		}
+		re, err := regexp.Compile(p.tok.value)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return &matchNode{x: l, re: re, negate: op == "!~"}, nil
+	}
+	return l, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	l, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		op := p.tok.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		r, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		l = &arithNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseProduct() (node, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") || p.isOp("%") {
+		op := p.tok.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &arithNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if !p.isOp("-") {
+		return p.parseOperand()
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	// A negative number is a literal, so that the smallest int64 can be
+	// written.
+	if p.tok.kind == tokenNumber {
+		p.tok.value = "-" + p.tok.value
+		return p.parseOperand()
+	}
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &negNode{x: x}, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenNumber:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if i, err := strconv.ParseInt(tok.value, 10, 64); err == nil {
+			return &literal{value: i}, nil
+		}
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			p.tok = tok
+			return nil, p.errorf("invalid number %q", tok.value)
+		}
+		return &literal{value: f}, nil
+	case tokenString:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return &literal{value: tok.value}, nil
+	case tokenIdent:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		switch tok.value {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "name":
+			return &nameRef{}, nil
+		case "fields", "tags":
+			key, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if tok.value == "fields" {
+				return &fieldRef{key: key}, nil
+			}
+			return &tagRef{key: key}, nil
+		}
+		p.tok = tok
+		if p.keyEnd > 0 && tok.pos == p.keyEnd+1 {
+			// such as fields.bytes-sent
+			key := p.src[p.keyStart : tok.pos+len(tok.value)]
+			return nil, p.errorf("unknown identifier %q, write a key containing operators as fields[%q]", tok.value, key)
+		}
+		return nil, p.errorf("unknown identifier %q", tok.value)
+	case tokenOp:
+		if tok.value == "(" {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOp(")") {
+				return nil, p.errorf("expected \")\"")
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	case tokenEOF:
+		return nil, p.errorf("unexpected end of expression")
+	}
+	return nil, p.errorf("unexpected %q", tok.value)
+}
+
+// parseKey parses the key following fields or tags.
+func (p *parser) parseKey() (string, error) {
+	switch {
+	case p.tok.kind == tokenOp && p.tok.value == ".":
+		if err := p.nextKey(); err != nil {
+			return "", err
+		}
+		key := p.tok.value
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		return key, nil
+	case p.isOp("["):
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		if p.tok.kind != tokenString {
+			return "", p.errorf("expected string")
+		}
+		key := p.tok.value
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		if !p.isOp("]") {
+			return "", p.errorf("expected \"]\"")
+		}
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		return key, nil
+	}
+	return "", p.errorf("expected \".\" or \"[\"")
+}
+
+var operators = []string{
+	"&&", "||", "==", "!=", "<=", ">=", "=~", "!~",
+	"!", "<", ">", "(", ")", "[", "]", ".",
+	"+", "-", "*", "/", "%",
+}
+
+// next reads the next token.
+func (p *parser) next() error {
+	prev := p.tok
+	p.skipSpace()
+	p.tok = token{pos: p.pos}
+	if p.pos >= len(p.src) {
+		p.tok.kind = tokenEOF
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c == '"' || c == '\'':
+		return p.lexString(c)
+	case c == '/' && prev.kind == tokenOp && (prev.value == "=~" || prev.value == "!~"):
+		return p.lexRegex()
+	case isDigit(c):
+		return p.lexNumber()
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' ||
+			unicode.IsLetter(rune(p.src[p.pos])) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok.kind = tokenIdent
+		p.tok.value = p.src[start:p.pos]
+		return nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			p.tok.kind = tokenOp
+			p.tok.value = op
+			return nil
+		}
+	}
+
+	p.tok.value = string(c)
+	return p.errorf("unexpected %q", c)
+}
+
+// nextKey reads a key following a dot, it ends at a space, bracket or
+// operator other than ".", so fields.used/fields.total is a division.  Keys
+// containing operators are written as fields["<key>"].
+func (p *parser) nextKey() error {
+	p.tok = token{kind: tokenKey, pos: p.pos}
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n()[]!=<>&|~+-*/%", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return p.errorf("expected key")
+	}
+	p.tok.value = p.src[start:p.pos]
+	p.keyStart, p.keyEnd = start, p.pos
+	return nil
+}
+
+func (p *parser) lexString(quote byte) error {
+	var b strings.Builder
+	p.pos++
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			p.tok.kind = tokenString
+			p.tok.value = b.String()
+			return nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+		p.pos++
+	}
+	return p.errorf("unterminated string")
+}
+
+func (p *parser) lexRegex() error {
+	var b strings.Builder
+	p.pos++
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '/':
+			p.pos++
+			p.tok.kind = tokenRegex
+			p.tok.value = b.String()
+			return nil
+		case c == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '/':
+			b.WriteByte('/')
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+		p.pos++
+	}
+	return p.errorf("unterminated regular expression")
+}
+
+func (p *parser) lexNumber() error {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if isDigit(c) || c == '.' || c == 'e' || c == 'E' ||
+			((c == '+' || c == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	p.tok.kind = tokenNumber
+	p.tok.value = p.src[start:p.pos]
+	return nil
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
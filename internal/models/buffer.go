@@ -1,6 +1,7 @@
 package models
 
 import (
+	"log"
 	"sync"
 
 	"github.com/influxdata/telegraf"
@@ -15,6 +16,7 @@ var (
 // Buffer stores metrics in a circular buffer.
 type Buffer struct {
 	sync.Mutex
+	name  string
 	buf   []telegraf.Metric
 	first int // index of the first/oldest metric
 	last  int // one after the index of the last/newest metric
@@ -24,6 +26,8 @@ type Buffer struct {
 	batchFirst int // index of the first metric in the batch
 	batchSize  int // number of metrics currently in the batch
 
+	log *diskLog // optional write-ahead log of the buffer contents
+
 	MetricsAdded   selfstat.Stat
 	MetricsWritten selfstat.Stat
 	MetricsDropped selfstat.Stat
@@ -34,6 +38,7 @@ type Buffer struct {
 // NewBuffer returns a new empty Buffer with the given capacity.
 func NewBuffer(name string, capacity int) *Buffer {
 	b := &Buffer{
+		name:  name,
 		buf:   make([]telegraf.Metric, capacity),
 		first: 0,
 		last:  0,
@@ -71,6 +76,50 @@ func NewBuffer(name string, capacity int) *Buffer {
 	return b
 }
 
+// Open enables persisting the buffer to the file at path and must be called
+// before any metrics are added.  Metrics left in the file by a previous run,
+// that were never written, are added back to the buffer.  When maxSize is
+// greater than zero the oldest metrics are dropped once the metrics in the
+// file exceed maxSize bytes.
+func (b *Buffer) Open(path string, maxSize int64, fsync string) error {
+	b.Lock()
+	defer b.Unlock()
+
+	l, metrics, err := openDiskLog(b.name, path, maxSize, fsync)
+	if err != nil {
+		return err
+	}
+	b.log = l
+
+	if len(metrics) > 0 {
+		log.Printf("I! [outputs.%s] Loaded %d metrics from disk buffer",
+			b.name, len(metrics))
+	}
+
+	for _, m := range metrics {
+		b.add(m)
+	}
+
+	b.commit(true)
+	b.BufferSize.Set(int64(b.length()))
+	return nil
+}
+
+// Close closes the file the buffer is persisted to.  Metrics remaining in the
+// buffer will be loaded again by Open.
+func (b *Buffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.log == nil {
+		return nil
+	}
+
+	err := b.log.close()
+	b.log = nil
+	return err
+}
+
 // Len returns the number of metrics currently in the buffer.
 func (b *Buffer) Len() int {
 	b.Lock()
@@ -83,22 +132,74 @@ func (b *Buffer) length() int {
 	return min(b.size+b.batchSize, b.cap)
 }
 
-func (b *Buffer) metricAdded() {
+func (b *Buffer) metricAdded(metric telegraf.Metric) {
 	b.MetricsAdded.Incr(1)
+	b.persistAdd(metric)
 }
 
 func (b *Buffer) metricWritten(metric telegraf.Metric) {
 	AgentMetricsWritten.Incr(1)
 	b.MetricsWritten.Incr(1)
+	b.persistRemove(metric)
 	metric.Accept()
 }
 
 func (b *Buffer) metricDropped(metric telegraf.Metric) {
 	AgentMetricsDropped.Incr(1)
 	b.MetricsDropped.Incr(1)
+	b.persistRemove(metric)
 	metric.Reject()
 }
 
+func (b *Buffer) persistAdd(metric telegraf.Metric) {
+	if b.log == nil {
+		return
+	}
+	if err := b.log.add(metric); err != nil {
+		b.closeLog(err)
+	}
+}
+
+func (b *Buffer) persistRemove(metric telegraf.Metric) {
+	if b.log == nil {
+		return
+	}
+	if err := b.log.remove(metric); err != nil {
+		b.closeLog(err)
+	}
+}
+
+// commit enforces the size limit of the disk buffer and writes out pending
+// records.  The write argument is true when the change is the result of a
+// write to the output.
+func (b *Buffer) commit(write bool) {
+	if b.log == nil {
+		return
+	}
+
+	for b.log.full() && b.size > 0 {
+		b.metricDropped(b.buf[b.first])
+		b.buf[b.first] = nil
+		b.first = b.next(b.first)
+		b.size--
+	}
+
+	if b.log == nil {
+		return
+	}
+	if err := b.log.commit(write); err != nil {
+		b.closeLog(err)
+	}
+}
+
+// closeLog stops persisting the buffer after an error, the buffer continues
+// to operate in memory only.
+func (b *Buffer) closeLog(err error) {
+	log.Printf("E! [outputs.%s] Disabling disk buffer after error: %v", b.name, err)
+	b.log.close()
+	b.log = nil
+}
+
 func (b *Buffer) add(m telegraf.Metric) {
 	// Check if Buffer is full
 	if b.size == b.cap {
@@ -110,7 +211,7 @@ func (b *Buffer) add(m telegraf.Metric) {
 		}
 	}
 
-	b.metricAdded()
+	b.metricAdded(m)
 
 	b.buf[b.last] = m
 	b.last = b.next(b.last)
@@ -131,6 +232,7 @@ func (b *Buffer) Add(metrics ...telegraf.Metric) {
 		b.add(metrics[i])
 	}
 
+	b.commit(false)
 	b.BufferSize.Set(int64(b.length()))
 }
 
@@ -173,6 +275,7 @@ func (b *Buffer) Accept(batch []telegraf.Metric) {
 	}
 
 	b.resetBatch()
+	b.commit(true)
 	b.BufferSize.Set(int64(b.length()))
 }
 
@@ -222,6 +325,7 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 	}
 
 	b.resetBatch()
+	b.commit(true)
 	b.BufferSize.Set(int64(b.length()))
 }
 
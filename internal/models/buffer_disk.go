@@ -0,0 +1,533 @@
+package models
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Fsync policies for the disk buffer.
+const (
+	// FsyncAlways syncs the disk buffer after every change.
+	FsyncAlways = "always"
+	// FsyncFlush syncs the disk buffer after every write to the output.
+	FsyncFlush = "flush"
+	// FsyncNever leaves syncing the disk buffer to the operating system.
+	FsyncNever = "never"
+)
+
+const (
+	recordAdd    = 'a'
+	recordRemove = 'r'
+
+	recordHeaderSize = 8
+
+	// Files smaller than this are never compacted unless empty.
+	compactMinSize = 1024 * 1024
+)
+
+const (
+	fieldInt = iota
+	fieldUint
+	fieldFloat
+	fieldString
+	fieldBool
+)
+
+// corruptLogError is returned when the log could only be partially read,
+// most likely because the last write was interrupted.
+type corruptLogError struct {
+	offset int64
+	err    error
+}
+
+func (e *corruptLogError) Error() string {
+	return fmt.Sprintf("corrupt record at offset %d: %v", e.offset, e.err)
+}
+
+var errTruncated = errors.New("truncated record")
+var errChecksum = errors.New("checksum mismatch")
+
+type logEntry struct {
+	id   uint64
+	size int64
+}
+
+// diskLog is an append-only write-ahead log of the metrics in a Buffer.
+//
+// Every metric added to the buffer is appended as an add record, and every
+// metric leaving the buffer, either written or dropped, is appended as a
+// remove record.  Replaying the log yields the metrics that were never
+// acknowledged.  The log is compacted when most of it consists of records
+// for metrics no longer in the buffer.
+type diskLog struct {
+	path    string
+	maxSize int64
+	fsync   string
+
+	file *os.File
+	w    *bufio.Writer
+
+	size    int64 // size of the log file
+	live    int64 // size of the add records of metrics still in the buffer
+	nextID  uint64
+	entries map[telegraf.Metric][]logEntry
+
+	// replaced is set once the log being written has replaced the log it was
+	// opened from.
+	replaced bool
+
+	scratch []byte
+}
+
+// openDiskLog reads the log at path, returning the unacknowledged metrics
+// oldest first, and starts a new empty log in its place.  The previous log
+// is kept until commit is called.
+func openDiskLog(name, path string, maxSize int64, fsync string) (*diskLog, []telegraf.Metric, error) {
+	switch fsync {
+	case "":
+		fsync = FsyncFlush
+	case FsyncAlways, FsyncFlush, FsyncNever:
+	default:
+		return nil, nil, fmt.Errorf("invalid fsync policy %q", fsync)
+	}
+
+	metrics, err := readDiskLog(path)
+	if _, ok := err.(*corruptLogError); ok {
+		log.Printf("W! [outputs.%s] Recovered %d metrics from disk buffer: %v",
+			name, len(metrics), err)
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := &diskLog{
+		path:    path,
+		maxSize: maxSize,
+		fsync:   fsync,
+		file:    file,
+		w:       bufio.NewWriter(file),
+		entries: make(map[telegraf.Metric][]logEntry),
+	}
+	return l, metrics, nil
+}
+
+// readDiskLog returns the metrics from the log at path that have no remove
+// record, oldest first.  A missing file is treated as an empty log.
+func readDiskLog(path string) ([]telegraf.Metric, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(map[uint64]telegraf.Metric)
+	var offset int64
+	for len(data) > 0 {
+		corrupt := func(err error) ([]telegraf.Metric, error) {
+			return sortByID(pending), &corruptLogError{offset: offset, err: err}
+		}
+
+		if len(data) < recordHeaderSize {
+			return corrupt(errTruncated)
+		}
+		length := binary.BigEndian.Uint32(data[0:4])
+		checksum := binary.BigEndian.Uint32(data[4:8])
+		if uint64(len(data)-recordHeaderSize) < uint64(length) {
+			return corrupt(errTruncated)
+		}
+
+		payload := data[recordHeaderSize : recordHeaderSize+length]
+		if crc32.ChecksumIEEE(payload) != checksum {
+			return corrupt(errChecksum)
+		}
+
+		kind, id, m, err := decodeRecord(payload)
+		if err != nil {
+			return corrupt(err)
+		}
+
+		switch kind {
+		case recordAdd:
+			pending[id] = m
+		case recordRemove:
+			delete(pending, id)
+		}
+
+		data = data[recordHeaderSize+length:]
+		offset += int64(recordHeaderSize + length)
+	}
+
+	return sortByID(pending), nil
+}
+
+func sortByID(pending map[uint64]telegraf.Metric) []telegraf.Metric {
+	ids := make([]uint64, 0, len(pending))
+	for id := range pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	metrics := make([]telegraf.Metric, 0, len(ids))
+	for _, id := range ids {
+		metrics = append(metrics, pending[id])
+	}
+	return metrics
+}
+
+// full returns true if the metrics in the log exceed the maximum size.
+func (l *diskLog) full() bool {
+	return l.maxSize > 0 && l.live > l.maxSize
+}
+
+// add appends an add record for the metric.
+func (l *diskLog) add(m telegraf.Metric) error {
+	id := l.nextID
+	l.nextID++
+
+	size, err := l.writeRecord(encodeAdd(l.scratch[:0], id, m))
+	if err != nil {
+		return err
+	}
+
+	l.live += size
+	l.entries[m] = append(l.entries[m], logEntry{id: id, size: size})
+	return nil
+}
+
+// remove appends a remove record for the metric.
+func (l *diskLog) remove(m telegraf.Metric) error {
+	entries, ok := l.entries[m]
+	if !ok {
+		return nil
+	}
+
+	entry := entries[0]
+	if len(entries) == 1 {
+		delete(l.entries, m)
+	} else {
+		l.entries[m] = entries[1:]
+	}
+	l.live -= entry.size
+
+	_, err := l.writeRecord(encodeRemove(l.scratch[:0], entry.id))
+	return err
+}
+
+func (l *diskLog) writeRecord(payload []byte) (int64, error) {
+	l.scratch = payload
+
+	var header [recordHeaderSize]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(payload))
+
+	if _, err := l.w.Write(header[:]); err != nil {
+		return 0, err
+	}
+	if _, err := l.w.Write(payload); err != nil {
+		return 0, err
+	}
+
+	size := int64(recordHeaderSize + len(payload))
+	l.size += size
+	return size, nil
+}
+
+// commit writes all pending records to the file, compacting it if needed,
+// and syncs it according to the fsync policy.  When called for the first
+// time the new log replaces the log it was opened from.
+func (l *diskLog) commit(write bool) error {
+	switch {
+	case l.live == 0 && l.size > 0:
+		if err := l.truncate(); err != nil {
+			return err
+		}
+	case l.size > compactMinSize && l.size > 2*l.live:
+		if err := l.compact(); err != nil {
+			return err
+		}
+	}
+
+	if err := l.w.Flush(); err != nil {
+		return err
+	}
+
+	// The new log must be on disk before it replaces the previous one.
+	if !l.replaced {
+		if err := l.file.Sync(); err != nil {
+			return err
+		}
+		return l.replace(l.file)
+	}
+
+	if l.fsync == FsyncAlways || (write && l.fsync == FsyncFlush) {
+		return l.file.Sync()
+	}
+	return nil
+}
+
+// truncate discards the content of the log.
+func (l *diskLog) truncate() error {
+	l.w.Reset(l.file)
+	if err := l.file.Truncate(0); err != nil {
+		return err
+	}
+	l.size = 0
+	return nil
+}
+
+// compact rewrites the log with only the add records of the metrics still in
+// the buffer.
+func (l *diskLog) compact() error {
+	metrics := make(map[uint64]telegraf.Metric, len(l.entries))
+	for m, entries := range l.entries {
+		for _, entry := range entries {
+			metrics[entry.id] = m
+		}
+	}
+
+	file, err := os.OpenFile(l.path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+
+	l.w.Reset(file)
+	l.size = 0
+	l.live = 0
+	l.entries = make(map[telegraf.Metric][]logEntry, len(l.entries))
+	for _, m := range sortByID(metrics) {
+		if err := l.add(m); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := l.w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return l.replace(file)
+}
+
+// replace moves file over the log path and makes it the active file.
+func (l *diskLog) replace(file *os.File) error {
+	if err := os.Rename(file.Name(), l.path); err != nil {
+		return err
+	}
+
+	if l.file != file {
+		l.file.Close()
+	}
+	l.file = file
+	l.w.Reset(file)
+	l.replaced = true
+	return nil
+}
+
+// close flushes and syncs the log and closes the file.
+func (l *diskLog) close() error {
+	if err := l.w.Flush(); err != nil {
+		l.file.Close()
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
+
+func encodeRemove(buf []byte, id uint64) []byte {
+	buf = append(buf, recordRemove)
+	buf = appendUvarint(buf, id)
+	return buf
+}
+
+func encodeAdd(buf []byte, id uint64, m telegraf.Metric) []byte {
+	buf = append(buf, recordAdd)
+	buf = appendUvarint(buf, id)
+	buf = append(buf, byte(m.Type()))
+	buf = appendVarint(buf, m.Time().UnixNano())
+	buf = appendString(buf, m.Name())
+
+	tags := m.TagList()
+	buf = appendUvarint(buf, uint64(len(tags)))
+	for _, tag := range tags {
+		buf = appendString(buf, tag.Key)
+		buf = appendString(buf, tag.Value)
+	}
+
+	fields := m.FieldList()
+	buf = appendUvarint(buf, uint64(len(fields)))
+	for _, field := range fields {
+		buf = appendString(buf, field.Key)
+		switch v := field.Value.(type) {
+		case int64:
+			buf = append(buf, fieldInt)
+			buf = appendVarint(buf, v)
+		case uint64:
+			buf = append(buf, fieldUint)
+			buf = appendUvarint(buf, v)
+		case float64:
+			buf = append(buf, fieldFloat)
+			buf = appendUvarint(buf, math.Float64bits(v))
+		case string:
+			buf = append(buf, fieldString)
+			buf = appendString(buf, v)
+		case bool:
+			buf = append(buf, fieldBool)
+			if v {
+				buf = append(buf, 1)
+			} else {
+				buf = append(buf, 0)
+			}
+		}
+	}
+	return buf
+}
+
+func decodeRecord(buf []byte) (byte, uint64, telegraf.Metric, error) {
+	d := &decoder{buf: buf}
+
+	kind := d.byte()
+	id := d.uvarint()
+	if kind == recordRemove {
+		return kind, id, nil, d.err
+	}
+	if kind != recordAdd {
+		return kind, id, nil, fmt.Errorf("unknown record type %q", kind)
+	}
+
+	tp := telegraf.ValueType(d.byte())
+	tm := time.Unix(0, d.varint())
+	name := d.string()
+
+	tags := make(map[string]string)
+	for n := d.uvarint(); n > 0 && d.err == nil; n-- {
+		key := d.string()
+		tags[key] = d.string()
+	}
+
+	fields := make(map[string]interface{})
+	for n := d.uvarint(); n > 0 && d.err == nil; n-- {
+		key := d.string()
+		switch d.byte() {
+		case fieldInt:
+			fields[key] = d.varint()
+		case fieldUint:
+			fields[key] = d.uvarint()
+		case fieldFloat:
+			fields[key] = math.Float64frombits(d.uvarint())
+		case fieldString:
+			fields[key] = d.string()
+		case fieldBool:
+			fields[key] = d.byte() != 0
+		default:
+			d.err = fmt.Errorf("unknown field type for %q", key)
+		}
+	}
+	if d.err != nil {
+		return kind, id, nil, d.err
+	}
+
+	m, err := metric.New(name, tags, fields, tm, tp)
+	return kind, id, m, err
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// decoder reads the values of a record, remembering the first error.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.buf) == 0 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) string() string {
+	n := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	if uint64(len(d.buf)) < n {
+		d.err = io.ErrUnexpectedEOF
+		return ""
+	}
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func tempBufferPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	return filepath.Join(dir, "buffer"), func() { os.RemoveAll(dir) }
+}
+
+func openBuffer(t *testing.T, path string, capacity int, maxSize int64) *Buffer {
+	b := setup(NewBuffer("test", capacity))
+	err := b.Open(path, maxSize, FsyncAlways)
+	require.NoError(t, err)
+	return b
+}
+
+func TestDiskBuffer_ReplayUnwritten(t *testing.T) {
+	path, cleanup := tempBufferPath(t)
+	defer cleanup()
+
+	b := openBuffer(t, path, 5, 0)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	require.NoError(t, b.Close())
+
+	b = openBuffer(t, path, 5, 0)
+	defer b.Close()
+
+	require.Equal(t, 3, b.Len())
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(3),
+			MetricTime(2),
+			MetricTime(1),
+		}, batch)
+}
+
+func TestDiskBuffer_AcceptedNotReplayed(t *testing.T) {
+	path, cleanup := tempBufferPath(t)
+	defer cleanup()
+
+	b := openBuffer(t, path, 5, 0)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	batch := b.Batch(2)
+	b.Accept(batch)
+	require.NoError(t, b.Close())
+
+	b = openBuffer(t, path, 5, 0)
+	defer b.Close()
+
+	batch = b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+		}, batch)
+}
+
+func TestDiskBuffer_RejectedReplayed(t *testing.T) {
+	path, cleanup := tempBufferPath(t)
+	defer cleanup()
+
+	b := openBuffer(t, path, 5, 0)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	batch := b.Batch(2)
+	b.Reject(batch)
+	require.NoError(t, b.Close())
+
+	b = openBuffer(t, path, 5, 0)
+	defer b.Close()
+
+	require.Equal(t, 3, b.Len())
+}
+
+func TestDiskBuffer_DroppedNotReplayed(t *testing.T) {
+	path, cleanup := tempBufferPath(t)
+	defer cleanup()
+
+	b := openBuffer(t, path, 2, 0)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	require.NoError(t, b.Close())
+
+	b = openBuffer(t, path, 5, 0)
+	defer b.Close()
+
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(3),
+			MetricTime(2),
+		}, batch)
+}
+
+func TestDiskBuffer_MaxSizeDropsOldest(t *testing.T) {
+	path, cleanup := tempBufferPath(t)
+	defer cleanup()
+
+	size := int64(len(encodeAdd(nil, 0, MetricTime(1))) + recordHeaderSize)
+
+	b := openBuffer(t, path, 5, 2*size)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(3),
+			MetricTime(2),
+		}, batch)
+}
+
+func TestDiskBuffer_EmptyAfterWrite(t *testing.T) {
+	path, cleanup := tempBufferPath(t)
+	defer cleanup()
+
+	b := openBuffer(t, path, 5, 0)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2))
+	b.Accept(b.Batch(5))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), info.Size())
+}
+
+func TestDiskBuffer_TruncatedRecord(t *testing.T) {
+	path, cleanup := tempBufferPath(t)
+	defer cleanup()
+
+	b := openBuffer(t, path, 5, 0)
+	b.Add(MetricTime(1), MetricTime(2))
+	require.NoError(t, b.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-1))
+
+	b = openBuffer(t, path, 5, 0)
+	defer b.Close()
+
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+		}, batch)
+}
+
+func TestDiskBuffer_FieldTypes(t *testing.T) {
+	path, cleanup := tempBufferPath(t)
+	defer cleanup()
+
+	m, err := metric.New(
+		"cpu",
+		map[string]string{
+			"host": "localhost",
+		},
+		map[string]interface{}{
+			"int":    int64(-42),
+			"uint":   uint64(42),
+			"float":  42.5,
+			"string": "multi\nline \"string\"",
+			"bool":   true,
+		},
+		time.Unix(0, 42),
+		telegraf.Counter,
+	)
+	require.NoError(t, err)
+
+	b := openBuffer(t, path, 5, 0)
+	b.Add(m.Copy())
+	require.NoError(t, b.Close())
+
+	b = openBuffer(t, path, 5, 0)
+	defer b.Close()
+
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, batch)
+	require.Equal(t, telegraf.Counter, batch[0].Type())
+}
+
+func TestDiskBuffer_InvalidFsync(t *testing.T) {
+	path, cleanup := tempBufferPath(t)
+	defer cleanup()
+
+	b := NewBuffer("test", 5)
+	err := b.Open(path, 0, "sometimes")
+	require.Error(t, err)
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Circuit breaker states, as reported by the circuit_breaker_state stat.
+const (
+	circuitClosed   = 0
+	circuitOpen     = 1
+	circuitHalfOpen = 2
+)
+
+// ErrCircuitOpen is returned when writing to an output whose circuit breaker
+// is open.
+var ErrCircuitOpen = errors.New("circuit breaker open, writes are paused")
+
+// circuitBreaker pauses the writes of an output after too many consecutive
+// failed writes.  Once the timeout has passed a single write is let through,
+// closing the breaker when it succeeds and opening it again otherwise.
+//
+// Changes of the state are logged, so that writes refused while the breaker
+// is open do not need to be.
+type circuitBreaker struct {
+	sync.Mutex
+	name      string
+	threshold int
+	timeout   time.Duration
+
+	state     int
+	failures  int // consecutive failed writes
+	openUntil time.Time
+
+	State selfstat.Stat
+	Trips selfstat.Stat
+}
+
+func newCircuitBreaker(name string, threshold int, timeout time.Duration) *circuitBreaker {
+	cb := &circuitBreaker{
+		name:      name,
+		threshold: threshold,
+		timeout:   timeout,
+		State: selfstat.Register(
+			"write",
+			"circuit_breaker_state",
+			map[string]string{"output": name},
+		),
+		Trips: selfstat.Register(
+			"write",
+			"circuit_breaker_trips",
+			map[string]string{"output": name},
+		),
+	}
+	cb.State.Set(circuitClosed)
+	return cb
+}
+
+// allow returns ErrCircuitOpen if writes are paused.
+func (cb *circuitBreaker) allow() error {
+	if cb.threshold <= 0 {
+		return nil
+	}
+
+	cb.Lock()
+	defer cb.Unlock()
+
+	if cb.state == circuitOpen {
+		if time.Now().Before(cb.openUntil) {
+			return ErrCircuitOpen
+		}
+		cb.setState(circuitHalfOpen)
+	}
+	return nil
+}
+
+// record updates the breaker with the result of a write.
+func (cb *circuitBreaker) record(err error) {
+	if cb.threshold <= 0 {
+		return
+	}
+
+	cb.Lock()
+	defer cb.Unlock()
+
+	if err == nil {
+		cb.failures = 0
+		cb.setState(circuitClosed)
+		return
+	}
+
+	cb.failures++
+	if cb.state == circuitHalfOpen || cb.failures >= cb.threshold {
+		cb.openUntil = time.Now().Add(cb.timeout)
+		cb.setState(circuitOpen)
+		cb.Trips.Incr(1)
+	}
+}
+
+func (cb *circuitBreaker) setState(state int) {
+	if state == cb.state {
+		return
+	}
+
+	switch state {
+	case circuitOpen:
+		log.Printf("E! [outputs.%s] Circuit breaker open after %d failed writes, pausing writes for %s",
+			cb.name, cb.failures, cb.timeout)
+	case circuitHalfOpen:
+		log.Printf("D! [outputs.%s] Circuit breaker half open, trying a write", cb.name)
+	case circuitClosed:
+		log.Printf("I! [outputs.%s] Circuit breaker closed, resuming writes", cb.name)
+	}
+
+	cb.state = state
+	cb.State.Set(int64(state))
+}
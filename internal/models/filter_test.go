@@ -45,6 +45,42 @@ func TestFilter_ApplyTagsDontPass(t *testing.T) {
 	require.False(t, f.Select(m))
 }
 
+func TestFilter_MetricPass(t *testing.T) {
+	f := Filter{
+		NamePass:   []string{"cpu"},
+		MetricPass: `fields.usage_idle < 10 && tags.host =~ /^web/`,
+	}
+	require.NoError(t, f.Compile())
+	require.True(t, f.IsActive())
+
+	tests := []struct {
+		name     string
+		host     string
+		idle     float64
+		expected bool
+	}{
+		{"cpu", "web01", 5, true},
+		{"cpu", "web01", 50, false},
+		{"cpu", "db01", 5, false},
+		{"mem", "web01", 5, false},
+	}
+	for _, tt := range tests {
+		m, err := metric.New(tt.name,
+			map[string]string{"host": tt.host},
+			map[string]interface{}{"usage_idle": tt.idle},
+			time.Now())
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, f.Select(m))
+	}
+}
+
+func TestFilter_MetricPassInvalid(t *testing.T) {
+	f := Filter{
+		MetricPass: `fields.usage_idle <`,
+	}
+	require.Error(t, f.Compile())
+}
+
 func TestFilter_ApplyDeleteFields(t *testing.T) {
 	f := Filter{
 		FieldDrop: []string{"value"},
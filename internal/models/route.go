@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// RouteTag is the reserved tag holding the route of a metric.  It is set by
+// the route option of inputs and can be set or changed by processors.  The
+// tag is removed before the metric is added to an output.
+const RouteTag = "_route"
+
+var (
+	AgentMetricsUnrouted = selfstat.Register("agent", "metrics_unrouted", map[string]string{})
+)
+
+// CompileRoutes compiles the Routes of the output.
+func (c *OutputConfig) CompileRoutes() error {
+	var err error
+	c.routeFilter, err = filter.Compile(c.Routes)
+	return err
+}
+
+// AcceptsRoute returns true if metrics with the route should be written to
+// the output.  Metrics without a route are written to the outputs without
+// Routes, metrics with a route to the outputs with a matching route.
+func (c *OutputConfig) AcceptsRoute(route string) bool {
+	if route == "" || len(c.Routes) == 0 {
+		return route == "" && len(c.Routes) == 0
+	}
+	return c.routeFilter != nil && c.routeFilter.Match(route)
+}
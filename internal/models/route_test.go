@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []string
+		route  string
+		accept bool
+	}{
+		{name: "unrouted to default output", route: "", accept: true},
+		{name: "routed to default output", route: "a", accept: false},
+		{name: "unrouted to routed output", routes: []string{"a"}, route: "", accept: false},
+		{name: "matching route", routes: []string{"a", "b"}, route: "b", accept: true},
+		{name: "other route", routes: []string{"a", "b"}, route: "c", accept: false},
+		{name: "glob route", routes: []string{"app.*"}, route: "app.web", accept: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &OutputConfig{Routes: tt.routes}
+			require.NoError(t, c.CompileRoutes())
+			require.Equal(t, tt.accept, c.AcceptsRoute(tt.route))
+		})
+	}
+}
@@ -14,6 +14,7 @@ type RunningAggregator struct {
 	sync.Mutex
 	Aggregator  telegraf.Aggregator
 	Config      *AggregatorConfig
+	Hash        uint64 // see RunningInput.Hash
 	periodStart time.Time
 	periodEnd   time.Time
 
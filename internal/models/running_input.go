@@ -13,6 +13,10 @@ type RunningInput struct {
 	Input  telegraf.Input
 	Config *InputConfig
 
+	// Hash identifies the configuration of the plugin, it is equal for
+	// plugins loaded from identical configuration tables.
+	Hash uint64
+
 	defaultTags map[string]string
 
 	MetricsGathered selfstat.Stat
@@ -46,6 +50,9 @@ type InputConfig struct {
 	MeasurementSuffix string
 	Tags              map[string]string
 	Filter            Filter
+
+	// Route is set as the RouteTag of the metrics of the input.
+	Route string
 }
 
 func (r *RunningInput) Name() string {
@@ -76,6 +83,10 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		return nil
 	}
 
+	if r.Config.Route != "" {
+		m.AddTag(RouteTag, r.Config.Route)
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return m
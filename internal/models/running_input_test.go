@@ -44,6 +44,41 @@ func TestMakeMetricFilterAfterApplyingGlobalTags(t *testing.T) {
 	testutil.RequireMetricEqual(t, expected, actual)
 }
 
+func TestMakeMetricRoute(t *testing.T) {
+	now := time.Now()
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:  "TestRunningInput",
+		Route: "app",
+		Filter: Filter{
+			TagInclude: []string{"b"},
+		},
+	})
+	require.NoError(t, ri.Config.Filter.Compile())
+
+	m, err := metric.New("cpu",
+		map[string]string{"a": "x", "b": "y"},
+		map[string]interface{}{
+			"value": 42,
+		},
+		now)
+	require.NoError(t, err)
+
+	actual := ri.MakeMetric(m)
+
+	expected, err := metric.New("cpu",
+		map[string]string{
+			"b":      "y",
+			RouteTag: "app",
+		},
+		map[string]interface{}{
+			"value": 42,
+		},
+		now)
+	require.NoError(t, err)
+
+	testutil.RequireMetricEqual(t, expected, actual)
+}
+
 func TestMakeMetricNoFields(t *testing.T) {
 	now := time.Now()
 	ri := NewRunningInput(&testInput{}, &InputConfig{
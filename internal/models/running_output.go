@@ -1,12 +1,15 @@
 package models
 
 import (
+	"context"
 	"log"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -16,6 +19,15 @@ const (
 
 	// Default number of metrics kept. It should be a multiple of batch size.
 	DEFAULT_METRIC_BUFFER_LIMIT = 10000
+
+	// Default delay before the first retry of a failed write.
+	DEFAULT_RETRY_INTERVAL = time.Second
+
+	// Default maximum delay between retries of a failed write.
+	DEFAULT_RETRY_MAX_INTERVAL = 30 * time.Second
+
+	// Default time writes are paused when the circuit breaker opens.
+	DEFAULT_CIRCUIT_BREAKER_TIMEOUT = time.Minute
 )
 
 // OutputConfig containing name and filter
@@ -26,6 +38,30 @@ type OutputConfig struct {
 	FlushInterval     time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+
+	// BufferPath is the file the buffer is persisted to, when empty the
+	// buffer is only kept in memory.
+	BufferPath    string
+	BufferMaxSize int64
+	BufferFsync   string
+
+	// RetryMax is the number of times a failed write is retried before the
+	// batch is returned to the buffer.  The delay between retries starts at
+	// RetryInterval and doubles up to RetryMaxInterval.
+	RetryMax         int
+	RetryInterval    time.Duration
+	RetryMaxInterval time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive failed writes
+	// after which writes are paused for CircuitBreakerTimeout, when zero
+	// writes are never paused.
+	CircuitBreakerThreshold int
+	CircuitBreakerTimeout   time.Duration
+
+	// Routes are the routes of the metrics written to the output, see
+	// AcceptsRoute.
+	Routes      []string
+	routeFilter filter.Filter
 }
 
 // RunningOutput contains the output configuration
@@ -36,15 +72,19 @@ type RunningOutput struct {
 	Name              string
 	Output            telegraf.Output
 	Config            *OutputConfig
+	Hash              uint64 // see RunningInput.Hash
 	MetricBufferLimit int
 	MetricBatchSize   int
 
 	MetricsFiltered selfstat.Stat
 	WriteTime       selfstat.Stat
+	WriteRetries    selfstat.Stat
+	WriteErrors     selfstat.Stat
 
 	BatchReady chan time.Time
 
-	buffer *Buffer
+	buffer  *Buffer
+	breaker *circuitBreaker
 
 	aggMutex sync.Mutex
 }
@@ -68,9 +108,20 @@ func NewRunningOutput(
 	if batchSize == 0 {
 		batchSize = DEFAULT_METRIC_BATCH_SIZE
 	}
+	if conf.RetryInterval == 0 {
+		conf.RetryInterval = DEFAULT_RETRY_INTERVAL
+	}
+	if conf.RetryMaxInterval == 0 {
+		conf.RetryMaxInterval = DEFAULT_RETRY_MAX_INTERVAL
+	}
+	if conf.CircuitBreakerTimeout == 0 {
+		conf.CircuitBreakerTimeout = DEFAULT_CIRCUIT_BREAKER_TIMEOUT
+	}
 	ro := &RunningOutput{
-		Name:              name,
-		buffer:            NewBuffer(name, bufferLimit),
+		Name:   name,
+		buffer: NewBuffer(name, bufferLimit),
+		breaker: newCircuitBreaker(name, conf.CircuitBreakerThreshold,
+			conf.CircuitBreakerTimeout),
 		BatchReady:        make(chan time.Time, 1),
 		Output:            output,
 		Config:            conf,
@@ -86,6 +137,16 @@ func NewRunningOutput(
 			"write_time_ns",
 			map[string]string{"output": name},
 		),
+		WriteRetries: selfstat.Register(
+			"write",
+			"write_retries",
+			map[string]string{"output": name},
+		),
+		WriteErrors: selfstat.Register(
+			"write",
+			"write_errors",
+			map[string]string{"output": name},
+		),
 	}
 
 	return ro
@@ -133,6 +194,16 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (ro *RunningOutput) Write() error {
+	return ro.WriteContext(context.Background())
+}
+
+// WriteContext is Write, failed writes are not retried once the context is
+// done.
+func (ro *RunningOutput) WriteContext(ctx context.Context) error {
+	if err := ro.breaker.allow(); err != nil {
+		return err
+	}
+
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		metrics := output.Push()
@@ -153,7 +224,7 @@ func (ro *RunningOutput) Write() error {
 			break
 		}
 
-		err := ro.write(batch)
+		err := ro.write(ctx, batch)
 		if err != nil {
 			ro.buffer.Reject(batch)
 			return err
@@ -165,12 +236,22 @@ func (ro *RunningOutput) Write() error {
 
 // WriteBatch writes a single batch of metrics to the output.
 func (ro *RunningOutput) WriteBatch() error {
+	return ro.WriteBatchContext(context.Background())
+}
+
+// WriteBatchContext is WriteBatch, failed writes are not retried once the
+// context is done.
+func (ro *RunningOutput) WriteBatchContext(ctx context.Context) error {
+	if err := ro.breaker.allow(); err != nil {
+		return err
+	}
+
 	batch := ro.buffer.Batch(ro.MetricBatchSize)
 	if len(batch) == 0 {
 		return nil
 	}
 
-	err := ro.write(batch)
+	err := ro.write(ctx, batch)
 	if err != nil {
 		ro.buffer.Reject(batch)
 		return err
@@ -180,14 +261,83 @@ func (ro *RunningOutput) WriteBatch() error {
 	return nil
 }
 
+// OpenBuffer loads metrics left from a previous run and starts persisting
+// the buffer if the output has a buffer path configured.
+func (ro *RunningOutput) OpenBuffer() error {
+	if ro.Config.BufferPath == "" {
+		return nil
+	}
+
+	return ro.buffer.Open(ro.Config.BufferPath, ro.Config.BufferMaxSize,
+		ro.Config.BufferFsync)
+}
+
 func (ro *RunningOutput) Close() {
 	err := ro.Output.Close()
 	if err != nil {
 		log.Printf("E! [outputs.%s] Error closing output: %v", ro.Name, err)
 	}
+
+	err = ro.buffer.Close()
+	if err != nil {
+		log.Printf("E! [outputs.%s] Error closing disk buffer: %v", ro.Name, err)
+	}
 }
 
-func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
+// write writes a batch to the output, retrying failed writes as configured
+// until the context is done.
+func (ro *RunningOutput) write(ctx context.Context, metrics []telegraf.Metric) error {
+	var err error
+	for retry := 0; ; retry++ {
+		err = ro.writeOnce(metrics)
+		if err == nil || retry >= ro.Config.RetryMax || !ro.waitRetry(ctx, retry, err) {
+			break
+		}
+	}
+
+	if err != nil {
+		ro.WriteErrors.Incr(1)
+	}
+	ro.breaker.record(err)
+	return err
+}
+
+// waitRetry waits for the delay before the retry, it returns false without
+// waiting when the context is done.
+func (ro *RunningOutput) waitRetry(ctx context.Context, retry int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	delay := ro.retryDelay(retry)
+	log.Printf("W! [outputs.%s] Error writing batch, retrying in %s: %v",
+		ro.Name, delay, err)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		ro.WriteRetries.Incr(1)
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryDelay returns the delay before the given retry, doubling the delay
+// with each retry and adding jitter so outputs do not retry in lockstep.
+func (ro *RunningOutput) retryDelay(retry int) time.Duration {
+	delay := ro.Config.RetryInterval
+	for i := 0; i < retry && delay < ro.Config.RetryMaxInterval; i++ {
+		delay *= 2
+	}
+	if delay > ro.Config.RetryMaxInterval {
+		delay = ro.Config.RetryMaxInterval
+	}
+	return delay/2 + internal.RandomDuration(delay/2)
+}
+
+func (ro *RunningOutput) writeOnce(metrics []telegraf.Metric) error {
 	start := time.Now()
 	err := ro.Output.Write(metrics)
 	elapsed := time.Since(start)
@@ -200,6 +350,11 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 	return err
 }
 
+// BufferLength returns the number of metrics in the buffer.
+func (ro *RunningOutput) BufferLength() int {
+	return ro.buffer.Len()
+}
+
 func (ro *RunningOutput) LogBufferStatus() {
 	nBuffer := ro.buffer.Len()
 	log.Printf("D! [outputs.%s] buffer fullness: %d / %d metrics. ",
@@ -1,9 +1,11 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
@@ -412,6 +414,139 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+func TestRunningOutputRetry(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		RetryMax:         3,
+		RetryInterval:    time.Millisecond,
+		RetryMaxInterval: 2 * time.Millisecond,
+	}
+
+	m := &mockOutput{failures: 2}
+	ro := NewRunningOutput("retry", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	err := ro.Write()
+	require.NoError(t, err)
+	require.Len(t, m.Metrics(), 5)
+	require.Equal(t, 3, m.writes)
+	require.Equal(t, int64(2), ro.WriteRetries.Get())
+	require.Equal(t, int64(0), ro.WriteErrors.Get())
+}
+
+func TestRunningOutputRetryExhausted(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		RetryMax:         2,
+		RetryInterval:    time.Millisecond,
+		RetryMaxInterval: time.Millisecond,
+	}
+
+	m := &mockOutput{failWrite: true}
+	ro := NewRunningOutput("retry_exhausted", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	err := ro.Write()
+	require.Error(t, err)
+	require.Equal(t, 3, m.writes)
+	require.Equal(t, int64(1), ro.WriteErrors.Get())
+	require.Equal(t, 5, ro.BufferLength())
+}
+
+func TestRunningOutputRetryCanceled(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		RetryMax:         5,
+		RetryInterval:    time.Hour,
+		RetryMaxInterval: time.Hour,
+	}
+
+	m := &mockOutput{failWrite: true}
+	ro := NewRunningOutput("retry_canceled", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// the wait for the retry stops when the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	require.Error(t, ro.WriteContext(ctx))
+	require.Equal(t, 1, m.writes)
+	require.Equal(t, int64(0), ro.WriteRetries.Get())
+
+	// once the context is done failed writes are not retried
+	require.Error(t, ro.WriteBatchContext(ctx))
+	require.Equal(t, 2, m.writes)
+	require.Equal(t, 5, ro.BufferLength())
+}
+
+func TestRunningOutputRetryDelay(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		RetryInterval:    time.Second,
+		RetryMaxInterval: 5 * time.Second,
+	}
+	ro := NewRunningOutput("retry_delay", &mockOutput{}, conf, 1000, 10000)
+
+	for retry, max := range map[int]time.Duration{
+		0:    time.Second,
+		1:    2 * time.Second,
+		2:    4 * time.Second,
+		3:    5 * time.Second,
+		1000: 5 * time.Second,
+	} {
+		delay := ro.retryDelay(retry)
+		require.True(t, delay >= max/2 && delay <= max,
+			"retry %d: delay %s not within %s", retry, delay, max)
+	}
+}
+
+func TestRunningOutputCircuitBreaker(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:                  Filter{},
+		CircuitBreakerThreshold: 2,
+		CircuitBreakerTimeout:   50 * time.Millisecond,
+	}
+
+	m := &mockOutput{failWrite: true}
+	ro := NewRunningOutput("circuit_breaker", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	require.Error(t, ro.Write())
+	require.Error(t, ro.Write())
+	require.Equal(t, int64(circuitOpen), ro.breaker.State.Get())
+	require.Equal(t, int64(1), ro.breaker.Trips.Get())
+
+	// writes are paused without calling the output
+	require.Equal(t, ErrCircuitOpen, ro.Write())
+	require.Equal(t, ErrCircuitOpen, ro.WriteBatch())
+	require.Equal(t, 2, m.writes)
+
+	// a failed write after the timeout pauses again
+	time.Sleep(conf.CircuitBreakerTimeout)
+	require.Error(t, ro.Write())
+	require.Equal(t, 3, m.writes)
+	require.Equal(t, ErrCircuitOpen, ro.Write())
+	require.Equal(t, int64(2), ro.breaker.Trips.Get())
+
+	// a successful write after the timeout resumes writes
+	time.Sleep(conf.CircuitBreakerTimeout)
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	require.Equal(t, int64(circuitClosed), ro.breaker.State.Get())
+	require.Len(t, m.Metrics(), 5)
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -419,6 +554,10 @@ type mockOutput struct {
 
 	// if true, mock a write failure
 	failWrite bool
+	// number of writes to fail before writing successfully
+	failures int
+	// number of calls to Write
+	writes int
 }
 
 func (m *mockOutput) Connect() error {
@@ -440,9 +579,14 @@ func (m *mockOutput) SampleConfig() string {
 func (m *mockOutput) Write(metrics []telegraf.Metric) error {
 	m.Lock()
 	defer m.Unlock()
+	m.writes++
 	if m.failWrite {
 		return fmt.Errorf("Failed Write!")
 	}
+	if m.failures > 0 {
+		m.failures--
+		return fmt.Errorf("Failed Write!")
+	}
 
 	if m.metrics == nil {
 		m.metrics = []telegraf.Metric{}
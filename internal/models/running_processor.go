@@ -12,8 +12,25 @@ type RunningProcessor struct {
 	sync.Mutex
 	Processor telegraf.Processor
 	Config    *ProcessorConfig
+	Hash      uint64 // see RunningInput.Hash
+
+	// Workers holds a RunningProcessor with its own instance of the
+	// processor for each worker, the first one is the RunningProcessor
+	// itself.  It is empty when the processor does not use workers.
+	Workers []*RunningProcessor
 }
 
+// Processor stages, see ProcessorConfig.Stage.
+const (
+	// StagePreAggregation processors are applied to the metrics of the
+	// inputs, and again to the metrics of the aggregators.
+	StagePreAggregation = "pre_aggregation"
+
+	// StagePostAggregation processors are applied to all metrics after the
+	// aggregators, before they are written to the outputs.
+	StagePostAggregation = "post_aggregation"
+)
+
 type RunningProcessors []*RunningProcessor
 
 func (rp RunningProcessors) Len() int           { return len(rp) }
@@ -25,6 +42,13 @@ type ProcessorConfig struct {
 	Name   string
 	Order  int64
 	Filter Filter
+
+	// Workers is the number of goroutines the processor is applied on.
+	Workers int
+
+	// Stage is when the processor is applied, StagePreAggregation or
+	// StagePostAggregation.
+	Stage string
 }
 
 func (rp *RunningProcessor) metricFiltered(metric telegraf.Metric) {
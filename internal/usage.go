@@ -11,6 +11,7 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+  config check        check the configuration files and print the problems found
   version             print the version to stdout
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
@@ -45,6 +46,9 @@ Examples:
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
+  # check a configuration file, exits with 1 if there are problems
+  telegraf --config telegraf.conf config check
+
   # run telegraf with all plugins defined in config file
   telegraf --config telegraf.conf
 
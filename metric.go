@@ -27,6 +27,14 @@ type Field struct {
 	Value interface{}
 }
 
+// FieldMeta is optional metadata describing the values of a field.
+type FieldMeta struct {
+	// Unit is the unit of the value, such as "bytes" or "seconds".
+	Unit string
+	// Description is a human readable description of the field.
+	Description string
+}
+
 type Metric interface {
 	// Getting data structure functions
 	Name() string
@@ -54,6 +62,10 @@ type Metric interface {
 	AddField(key string, value interface{})
 	RemoveField(key string)
 
+	// Field metadata functions
+	GetFieldMeta(key string) (FieldMeta, bool)
+	SetFieldMeta(key string, meta FieldMeta)
+
 	SetTime(t time.Time)
 
 	// HashID returns an unique identifier for the series.
@@ -17,6 +17,8 @@ type metric struct {
 
 	tp        telegraf.ValueType
 	aggregate bool
+
+	meta map[string]telegraf.FieldMeta
 }
 
 func New(
@@ -80,6 +82,9 @@ func FromMetric(other telegraf.Metric) telegraf.Metric {
 
 	for i, field := range other.FieldList() {
 		m.fields[i] = &telegraf.Field{Key: field.Key, Value: field.Value}
+		if meta, ok := other.GetFieldMeta(field.Key); ok {
+			m.SetFieldMeta(field.Key, meta)
+		}
 	}
 	return m
 }
@@ -220,11 +225,24 @@ func (m *metric) RemoveField(key string) {
 			copy(m.fields[i:], m.fields[i+1:])
 			m.fields[len(m.fields)-1] = nil
 			m.fields = m.fields[:len(m.fields)-1]
+			delete(m.meta, key)
 			return
 		}
 	}
 }
 
+func (m *metric) GetFieldMeta(key string) (telegraf.FieldMeta, bool) {
+	meta, ok := m.meta[key]
+	return meta, ok
+}
+
+func (m *metric) SetFieldMeta(key string, meta telegraf.FieldMeta) {
+	if m.meta == nil {
+		m.meta = make(map[string]telegraf.FieldMeta)
+	}
+	m.meta[key] = meta
+}
+
 func (m *metric) SetTime(t time.Time) {
 	m.tm = t
 }
@@ -246,6 +264,13 @@ func (m *metric) Copy() telegraf.Metric {
 	for i, field := range m.fields {
 		m2.fields[i] = field
 	}
+
+	if len(m.meta) > 0 {
+		m2.meta = make(map[string]telegraf.FieldMeta, len(m.meta))
+		for k, v := range m.meta {
+			m2.meta[k] = v
+		}
+	}
 	return m2
 }
 
@@ -144,6 +144,30 @@ func TestRemoveFieldNoEffectOnMissingFields(t *testing.T) {
 	require.Equal(t, "x", value)
 }
 
+func TestFieldMeta(t *testing.T) {
+	m := baseMetric()
+
+	_, ok := m.GetFieldMeta("value")
+	require.False(t, ok)
+
+	meta := telegraf.FieldMeta{Unit: "bytes", Description: "Bytes sent"}
+	m.SetFieldMeta("value", meta)
+	v, ok := m.GetFieldMeta("value")
+	require.True(t, ok)
+	require.Equal(t, meta, v)
+
+	m2 := m.Copy()
+	m2.SetFieldMeta("value", telegraf.FieldMeta{Unit: "bits"})
+	v, _ = m.GetFieldMeta("value")
+	require.Equal(t, meta, v)
+	v, _ = FromMetric(m).GetFieldMeta("value")
+	require.Equal(t, meta, v)
+
+	m.RemoveField("value")
+	_, ok = m.GetFieldMeta("value")
+	require.False(t, ok)
+}
+
 func TestGetField(t *testing.T) {
 	m := baseMetric()
 
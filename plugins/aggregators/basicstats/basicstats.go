@@ -3,6 +3,7 @@ package basicstats
 import (
 	"log"
 	"math"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/aggregators"
@@ -16,13 +17,19 @@ type BasicStats struct {
 }
 
 type configuredStats struct {
-	count    bool
-	min      bool
-	max      bool
-	mean     bool
-	variance bool
-	stdev    bool
-	sum      bool
+	count           bool
+	min             bool
+	max             bool
+	mean            bool
+	variance        bool
+	stdev           bool
+	sum             bool
+	first           bool
+	last            bool
+	diff            bool
+	rate            bool
+	nonNegativeRate bool
+	interval        bool
 }
 
 func NewBasicStats() *BasicStats {
@@ -44,6 +51,27 @@ type basicstats struct {
 	sum   float64
 	mean  float64
 	M2    float64 //intermedia value for variance/stdev
+
+	// value and time of the earliest and latest metric
+	first     float64
+	last      float64
+	firstTime time.Time
+	lastTime  time.Time
+}
+
+func newBasicstats(fv float64, tm time.Time) basicstats {
+	return basicstats{
+		count:     1,
+		min:       fv,
+		max:       fv,
+		mean:      fv,
+		sum:       fv,
+		M2:        0.0,
+		first:     fv,
+		last:      fv,
+		firstTime: tm,
+		lastTime:  tm,
+	}
 }
 
 var sampleConfig = `
@@ -54,6 +82,8 @@ var sampleConfig = `
   drop_original = false
 
   ## Configures which basic stats to push as fields
+  ## Available stats are "count", "min", "max", "mean", "stdev", "s2", "sum",
+  ## "first", "last", "diff", "rate", "non_negative_rate" and "interval".
   # stats = ["count", "min", "max", "mean", "stdev", "s2", "sum"]
 `
 
@@ -76,14 +106,7 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 		}
 		for _, field := range in.FieldList() {
 			if fv, ok := convert(field.Value); ok {
-				a.fields[field.Key] = basicstats{
-					count: 1,
-					min:   fv,
-					max:   fv,
-					mean:  fv,
-					sum:   fv,
-					M2:    0.0,
-				}
+				a.fields[field.Key] = newBasicstats(fv, in.Time())
 			}
 		}
 		m.cache[id] = a
@@ -92,14 +115,7 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 			if fv, ok := convert(field.Value); ok {
 				if _, ok := m.cache[id].fields[field.Key]; !ok {
 					// hit an uncached field of a cached metric
-					m.cache[id].fields[field.Key] = basicstats{
-						count: 1,
-						min:   fv,
-						max:   fv,
-						mean:  fv,
-						sum:   fv,
-						M2:    0.0,
-					}
+					m.cache[id].fields[field.Key] = newBasicstats(fv, in.Time())
 					continue
 				}
 
@@ -127,6 +143,16 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 				}
 				//sum compute
 				tmp.sum += fv
+				//first/last compute, by time so that metrics added out of
+				//order do not change the result
+				if in.Time().Before(tmp.firstTime) {
+					tmp.first = fv
+					tmp.firstTime = in.Time()
+				}
+				if !in.Time().Before(tmp.lastTime) {
+					tmp.last = fv
+					tmp.lastTime = in.Time()
+				}
 				//store final data
 				m.cache[id].fields[field.Key] = tmp
 			}
@@ -156,6 +182,12 @@ func (m *BasicStats) Push(acc telegraf.Accumulator) {
 			if config.sum {
 				fields[k+"_sum"] = v.sum
 			}
+			if config.first {
+				fields[k+"_first"] = v.first
+			}
+			if config.last {
+				fields[k+"_last"] = v.last
+			}
 
 			//v.count always >=1
 			if v.count > 1 {
@@ -167,6 +199,26 @@ func (m *BasicStats) Push(acc telegraf.Accumulator) {
 				if config.stdev {
 					fields[k+"_stdev"] = math.Sqrt(variance)
 				}
+
+				diff := v.last - v.first
+				interval := v.lastTime.Sub(v.firstTime)
+				if config.diff {
+					fields[k+"_diff"] = diff
+				}
+				if config.interval {
+					fields[k+"_interval"] = interval.Nanoseconds()
+				}
+				//the rate is per second over the time between the first
+				//and last metric, it is undefined if they have the same time
+				if interval > 0 {
+					rate := diff / interval.Seconds()
+					if config.rate {
+						fields[k+"_rate"] = rate
+					}
+					if config.nonNegativeRate && rate >= 0 {
+						fields[k+"_non_negative_rate"] = rate
+					}
+				}
 			}
 			//if count == 1 StdDev = infinite => so I won't send data
 		}
@@ -199,6 +251,18 @@ func parseStats(names []string) *configuredStats {
 			parsed.stdev = true
 		case "sum":
 			parsed.sum = true
+		case "first":
+			parsed.first = true
+		case "last":
+			parsed.last = true
+		case "diff":
+			parsed.diff = true
+		case "rate":
+			parsed.rate = true
+		case "non_negative_rate":
+			parsed.nonNegativeRate = true
+		case "interval":
+			parsed.interval = true
 
 		default:
 			log.Printf("W! Unrecognized basic stat '%s', ignoring", name)
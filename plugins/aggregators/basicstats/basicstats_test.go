@@ -530,3 +530,111 @@ func TestBasicStatsWithDefaultStats(t *testing.T) {
 	assert.True(t, acc.HasField("m1", "a_s2"))
 	assert.False(t, acc.HasField("m1", "a_sum"))
 }
+
+// Test that first, last, diff, rate and interval use the time of the metrics,
+// so that metrics added out of order give the same result.
+func TestBasicStatsWithRate(t *testing.T) {
+
+	var c1, _ = metric.New("m1",
+		map[string]string{},
+		map[string]interface{}{"a": int64(10)},
+		time.Unix(10, 0),
+	)
+	var c2, _ = metric.New("m1",
+		map[string]string{},
+		map[string]interface{}{"a": int64(15)},
+		time.Unix(15, 0),
+	)
+	var c3, _ = metric.New("m1",
+		map[string]string{},
+		map[string]interface{}{"a": int64(40)},
+		time.Unix(30, 0),
+	)
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"first", "last", "diff", "rate", "non_negative_rate", "interval"}
+
+	aggregator.Add(c2)
+	aggregator.Add(c3)
+	aggregator.Add(c1)
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"a_first":             float64(10),
+		"a_last":              float64(40),
+		"a_diff":              float64(30),
+		"a_rate":              float64(1.5),
+		"a_non_negative_rate": float64(1.5),
+		"a_interval":          int64(20 * time.Second),
+	}
+	expectedTags := map[string]string{}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
+
+// Test that non_negative_rate is not pushed when the value decreased, such
+// as after a counter reset.
+func TestBasicStatsWithNonNegativeRate(t *testing.T) {
+
+	var c1, _ = metric.New("m1",
+		map[string]string{},
+		map[string]interface{}{"a": int64(40)},
+		time.Unix(10, 0),
+	)
+	var c2, _ = metric.New("m1",
+		map[string]string{},
+		map[string]interface{}{"a": int64(20)},
+		time.Unix(20, 0),
+	)
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"rate", "non_negative_rate"}
+
+	aggregator.Add(c1)
+	aggregator.Add(c2)
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"a_rate": float64(-2),
+	}
+	expectedTags := map[string]string{}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+	assert.False(t, acc.HasField("m1", "a_non_negative_rate"))
+}
+
+// Test that the rate is not pushed for a single metric or metrics with the
+// same time.
+func TestBasicStatsWithRateSameTime(t *testing.T) {
+
+	var c1, _ = metric.New("m1",
+		map[string]string{},
+		map[string]interface{}{"a": int64(10), "b": int64(1)},
+		time.Unix(10, 0),
+	)
+	var c2, _ = metric.New("m1",
+		map[string]string{},
+		map[string]interface{}{"a": int64(20)},
+		time.Unix(10, 0),
+	)
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"diff", "rate", "interval"}
+
+	aggregator.Add(c1)
+	aggregator.Add(c2)
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"a_diff":     float64(10),
+		"a_interval": int64(0),
+	}
+	expectedTags := map[string]string{}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+	assert.False(t, acc.HasField("m1", "a_rate"))
+	assert.False(t, acc.HasField("m1", "b_diff"))
+}
@@ -0,0 +1,64 @@
+package merge
+
+import (
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+const sampleConfig = `
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = true
+`
+
+type Merge struct {
+	grouper *metric.SeriesGrouper
+}
+
+func NewMerge() *Merge {
+	m := &Merge{}
+	m.Reset()
+	return m
+}
+
+func (m *Merge) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *Merge) Description() string {
+	return "Merge metrics into multifield metrics by series key"
+}
+
+func (m *Merge) Add(in telegraf.Metric) {
+	tags := in.Tags()
+	for _, field := range in.FieldList() {
+		err := m.grouper.Add(in.Name(), tags, in.Time(), field.Key, field.Value)
+		if err != nil {
+			log.Printf("E! [aggregators.merge] Error adding metric: %v", err)
+		}
+	}
+}
+
+func (m *Merge) Push(acc telegraf.Accumulator) {
+	// Keep the timestamps of the original metrics, which may have a higher
+	// precision than the one of the agent.
+	acc.SetPrecision(time.Nanosecond)
+
+	for _, metric := range m.grouper.Metrics() {
+		acc.AddMetric(metric)
+	}
+}
+
+func (m *Merge) Reset() {
+	m.grouper = metric.NewSeriesGrouper()
+}
+
+func init() {
+	aggregators.Add("merge", func() telegraf.Aggregator {
+		return NewMerge()
+	})
+}
@@ -0,0 +1,126 @@
+package merge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestSimple(t *testing.T) {
+	plugin := NewMerge()
+
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_idle": 42},
+		time.Unix(0, 0),
+	))
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_guest": 42},
+		time.Unix(0, 0),
+	))
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{
+				"time_idle":  42,
+				"time_guest": 42,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestSeriesAndTime(t *testing.T) {
+	plugin := NewMerge()
+
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_idle": 42},
+		time.Unix(0, 0),
+	))
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu1"},
+		map[string]interface{}{"time_idle": 43},
+		time.Unix(0, 0),
+	))
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_guest": 44},
+		time.Unix(0, 1),
+	))
+	plugin.Add(testutil.MustMetric("mem",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"free": 45},
+		time.Unix(0, 0),
+	))
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_idle": 42},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu1"},
+			map[string]interface{}{"time_idle": 43},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_guest": 44},
+			time.Unix(0, 1),
+		),
+		testutil.MustMetric("mem",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"free": 45},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestReset(t *testing.T) {
+	plugin := NewMerge()
+
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_idle": 42},
+		time.Unix(0, 0),
+	))
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	plugin.Reset()
+
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_guest": 42},
+		time.Unix(0, 0),
+	))
+	plugin.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_idle": 42},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_guest": 42},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
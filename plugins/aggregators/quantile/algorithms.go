@@ -0,0 +1,59 @@
+package quantile
+
+import (
+	"math"
+	"sort"
+
+	"github.com/caio/go-tdigest"
+)
+
+// algorithm computes the quantiles of the values added to it.
+type algorithm interface {
+	Add(value float64) error
+	Quantile(q float64) float64
+}
+
+type newAlgorithmFunc func() (algorithm, error)
+
+func newTDigest(compression float64) newAlgorithmFunc {
+	return func() (algorithm, error) {
+		return tdigest.New(tdigest.Compression(uint32(compression)))
+	}
+}
+
+// exact keeps all the values to compute the exact quantiles.
+type exact struct {
+	values []float64
+	sorted bool
+}
+
+func newExact() (algorithm, error) {
+	return &exact{}, nil
+}
+
+func (e *exact) Add(value float64) error {
+	e.values = append(e.values, value)
+	e.sorted = false
+	return nil
+}
+
+// Quantile returns the quantile using linear interpolation between the
+// closest ranks, the R7 method of Hyndman and Fan.
+func (e *exact) Quantile(q float64) float64 {
+	n := len(e.values)
+	if n == 0 {
+		return math.NaN()
+	}
+	if !e.sorted {
+		sort.Float64s(e.values)
+		e.sorted = true
+	}
+
+	h := float64(n-1) * q
+	lower := math.Floor(h)
+	i := int(lower)
+	if i >= n-1 {
+		return e.values[n-1]
+	}
+	return e.values[i] + (h-lower)*(e.values[i+1]-e.values[i])
+}
@@ -0,0 +1,181 @@
+package quantile
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+type Quantile struct {
+	Quantiles   []float64 `toml:"quantiles"`
+	Algorithm   string    `toml:"algorithm"`
+	Compression float64   `toml:"compression"`
+
+	newAlgorithm newAlgorithmFunc
+	suffixes     []string
+	cache        map[uint64]aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]algorithm
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Quantiles to compute, between 0 and 1.  The fields are named after the
+  ## field and the percentile, for example "usage_p99" for 0.99.
+  # quantiles = [0.5, 0.9, 0.99]
+
+  ## Algorithm computing the quantiles:
+  ##   "t-digest" -- approximate, using a bounded amount of memory
+  ##   "exact"    -- exact, keeping all the values of the period
+  # algorithm = "t-digest"
+
+  ## Compression of the t-digest, higher values are more accurate and use
+  ## more memory.  It is rounded down to an integer, at least 1.
+  # compression = 100.0
+`
+
+func NewQuantile() *Quantile {
+	q := &Quantile{
+		Quantiles:   []float64{0.5, 0.9, 0.99},
+		Algorithm:   "t-digest",
+		Compression: 100,
+	}
+	q.Reset()
+	return q
+}
+
+func (q *Quantile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (q *Quantile) Description() string {
+	return "Keep the aggregate quantiles of each metric passing through."
+}
+
+func (q *Quantile) init() error {
+	switch q.Algorithm {
+	case "t-digest", "":
+		if q.Compression < 1 {
+			return fmt.Errorf("compression must be at least 1")
+		}
+		q.newAlgorithm = newTDigest(q.Compression)
+	case "exact":
+		q.newAlgorithm = newExact
+	default:
+		return fmt.Errorf("unknown algorithm %q", q.Algorithm)
+	}
+
+	suffixes := make([]string, 0, len(q.Quantiles))
+	for _, quantile := range q.Quantiles {
+		if quantile < 0 || quantile > 1 {
+			return fmt.Errorf("quantile %v is not between 0 and 1", quantile)
+		}
+		suffix := quantileSuffix(quantile)
+		for _, other := range suffixes {
+			if other == suffix {
+				return fmt.Errorf("quantile %v is a duplicate", quantile)
+			}
+		}
+		suffixes = append(suffixes, suffix)
+	}
+	q.suffixes = suffixes
+	return nil
+}
+
+// quantileSuffix returns the suffix of the field of the quantile, the
+// percentile without the decimal point: 0.5 is "_p50" and 0.999 "_p999".
+func quantileSuffix(quantile float64) string {
+	percentile := math.Round(quantile*1e8) / 1e6
+	s := strconv.FormatFloat(percentile, 'f', -1, 64)
+	return "_p" + strings.Replace(s, ".", "", 1)
+}
+
+func (q *Quantile) Add(in telegraf.Metric) {
+	if q.newAlgorithm == nil {
+		if err := q.init(); err != nil {
+			log.Printf("E! [aggregators.quantile] Error initializing: %v", err)
+			return
+		}
+	}
+
+	id := in.HashID()
+	a, ok := q.cache[id]
+	if !ok {
+		a = aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]algorithm),
+		}
+		q.cache[id] = a
+	}
+
+	for _, field := range in.FieldList() {
+		fv, ok := convert(field.Value)
+		if !ok {
+			continue
+		}
+		alg, ok := a.fields[field.Key]
+		if !ok {
+			var err error
+			alg, err = q.newAlgorithm()
+			if err != nil {
+				log.Printf("E! [aggregators.quantile] Error creating algorithm: %v", err)
+				return
+			}
+			a.fields[field.Key] = alg
+		}
+		if err := alg.Add(fv); err != nil {
+			log.Printf("E! [aggregators.quantile] Error adding %q: %v", field.Key, err)
+		}
+	}
+}
+
+func (q *Quantile) Push(acc telegraf.Accumulator) {
+	for _, a := range q.cache {
+		fields := make(map[string]interface{}, len(a.fields)*len(q.Quantiles))
+		for k, alg := range a.fields {
+			for i, quantile := range q.Quantiles {
+				fields[k+q.suffixes[i]] = alg.Quantile(quantile)
+			}
+		}
+		acc.AddFields(a.name, fields, a.tags)
+	}
+}
+
+func (q *Quantile) Reset() {
+	q.cache = make(map[uint64]aggregate)
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("quantile", func() telegraf.Aggregator {
+		return NewQuantile()
+	})
+}
@@ -0,0 +1,143 @@
+package quantile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func values(n int) []telegraf.Metric {
+	metrics := make([]telegraf.Metric, 0, n)
+	for i := 1; i <= n; i++ {
+		metrics = append(metrics, testutil.MustMetric("test",
+			map[string]string{"foo": "bar"},
+			map[string]interface{}{
+				"a":      int64(i),
+				"b":      float64(i) / 10,
+				"ignore": "string",
+			},
+			time.Now(),
+		))
+	}
+	return metrics
+}
+
+func TestQuantileExact(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+	q.Algorithm = "exact"
+	q.Quantiles = []float64{0, 0.25, 0.5, 0.99, 1}
+
+	for _, m := range values(101) {
+		q.Add(m)
+	}
+	q.Push(&acc)
+
+	expected := map[string]interface{}{
+		"a_p0":   1.0,
+		"a_p25":  26.0,
+		"a_p50":  51.0,
+		"a_p99":  100.0,
+		"a_p100": 101.0,
+		"b_p0":   0.1,
+		"b_p25":  2.6,
+		"b_p50":  5.1,
+		"b_p99":  10.0,
+		"b_p100": 10.1,
+	}
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "test", acc.Metrics[0].Measurement)
+	require.Equal(t, map[string]string{"foo": "bar"}, acc.Metrics[0].Tags)
+	require.Len(t, acc.Metrics[0].Fields, len(expected))
+	for k, v := range expected {
+		require.InDelta(t, v, acc.Metrics[0].Fields[k], 1e-9, k)
+	}
+}
+
+func TestQuantileExactInterpolation(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+	q.Algorithm = "exact"
+	q.Quantiles = []float64{0.5, 0.9}
+
+	for _, m := range values(4) {
+		q.Add(m)
+	}
+	q.Push(&acc)
+
+	require.InDelta(t, 2.5, acc.Metrics[0].Fields["a_p50"], 1e-9)
+	require.InDelta(t, 3.7, acc.Metrics[0].Fields["a_p90"], 1e-9)
+}
+
+func TestQuantileTDigest(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+
+	for _, m := range values(10000) {
+		q.Add(m)
+	}
+	q.Push(&acc)
+
+	require.Len(t, acc.Metrics, 1)
+	fields := acc.Metrics[0].Fields
+	require.Len(t, fields, 6)
+	require.InDelta(t, 5000.0, fields["a_p50"], 50)
+	require.InDelta(t, 9000.0, fields["a_p90"], 50)
+	require.InDelta(t, 9900.0, fields["a_p99"], 10)
+	require.InDelta(t, 500.0, fields["b_p50"], 5)
+}
+
+func TestQuantileReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+	q.Algorithm = "exact"
+
+	for _, m := range values(10) {
+		q.Add(m)
+	}
+	q.Reset()
+	q.Add(values(1)[0])
+	q.Push(&acc)
+
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, 1.0, acc.Metrics[0].Fields["a_p99"])
+}
+
+func TestQuantileSuffix(t *testing.T) {
+	require.Equal(t, "_p50", quantileSuffix(0.5))
+	require.Equal(t, "_p29", quantileSuffix(0.29))
+	require.Equal(t, "_p999", quantileSuffix(0.999))
+	require.Equal(t, "_p100", quantileSuffix(1))
+}
+
+func TestQuantileInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		q    *Quantile
+	}{
+		{
+			name: "unknown algorithm",
+			q:    &Quantile{Algorithm: "median", Quantiles: []float64{0.5}},
+		},
+		{
+			name: "quantile out of range",
+			q:    &Quantile{Algorithm: "exact", Quantiles: []float64{1.5}},
+		},
+		{
+			name: "duplicate quantile",
+			q:    &Quantile{Algorithm: "exact", Quantiles: []float64{0.5, 0.50}},
+		},
+		{
+			name: "compression",
+			q:    &Quantile{Algorithm: "t-digest", Quantiles: []float64{0.5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.q.init())
+		})
+	}
+}
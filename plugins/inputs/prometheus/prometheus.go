@@ -291,6 +291,12 @@ func (p *Prometheus) gatherURL(u URLAndAddress, acc telegraf.Accumulator) error
 			tags[k] = v
 		}
 
+		for _, field := range metric.FieldList() {
+			if meta, ok := metric.GetFieldMeta(field.Key); ok {
+				acc.SetFieldMeta(metric.Name(), field.Key, meta)
+			}
+		}
+
 		switch metric.Type() {
 		case telegraf.Counter:
 			acc.AddCounter(metric.Name(), metric.Fields(), tags, metric.Time())
@@ -18,6 +18,7 @@ type File struct {
 	RotationInterval    internal.Duration `toml:"rotation_interval"`
 	RotationMaxSize     internal.Size     `toml:"rotation_max_size"`
 	RotationMaxArchives int               `toml:"rotation_max_archives"`
+	UseBatchFormat      bool              `toml:"use_batch_format"`
 
 	writer     io.Writer
 	closers    []io.Closer
@@ -40,6 +41,12 @@ var sampleConfig = `
   ## If set to -1, no archives are removed.
   # rotation_max_archives = 5
 
+  ## Use batch serialization format instead of line based delimiting.  The
+  ## batch format allows for the production of non line based output formats,
+  ## such as the prometheus format with one TYPE line per metric family, and
+  ## may more efficiently encode metric groups.
+  # use_batch_format = false
+
   ## Data format to output.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -98,6 +105,20 @@ func (f *File) Description() string {
 func (f *File) Write(metrics []telegraf.Metric) error {
 	var writeErr error = nil
 
+	if f.UseBatchFormat {
+		b, err := f.serializer.SerializeBatch(metrics)
+		if err != nil {
+			log.Printf("D! [outputs.file] Could not serialize metrics: %v", err)
+			return nil
+		}
+
+		_, err = f.writer.Write(b)
+		if err != nil {
+			return fmt.Errorf("E! [outputs.file] failed to write message: %v", err)
+		}
+		return nil
+	}
+
 	for _, metric := range metrics {
 		b, err := f.serializer.Serialize(metric)
 		if err != nil {
@@ -6,9 +6,11 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
@@ -180,6 +182,42 @@ func TestFileStdout(t *testing.T) {
 	assert.Equal(t, expNewFile, out)
 }
 
+func TestFileBatchFormat(t *testing.T) {
+	s, _ := serializers.NewSerializer(&serializers.Config{DataFormat: "prometheus"})
+	fh := tmpFile()
+	defer os.Remove(fh)
+	f := File{
+		Files:          []string{fh},
+		UseBatchFormat: true,
+		serializer:     s,
+	}
+
+	err := f.Connect()
+	assert.NoError(t, err)
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_idle": 42.5},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu1"},
+			map[string]interface{}{"time_idle": 40.5},
+			time.Unix(0, 0),
+		),
+	}
+	err = f.Write(metrics)
+	assert.NoError(t, err)
+
+	validateFile(fh, "# TYPE cpu_time_idle untyped\n"+
+		"cpu_time_idle{cpu=\"cpu0\"} 42.5\n"+
+		"cpu_time_idle{cpu=\"cpu1\"} 40.5\n", t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
 func createFile() *os.File {
 	f, err := ioutil.TempFile("", "")
 	if err != nil {
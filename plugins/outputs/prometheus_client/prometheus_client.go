@@ -9,7 +9,6 @@ import (
 	"net"
 	"net/http"
 	"net/url"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -20,15 +19,11 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/prometheus"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var (
-	invalidNameCharRE = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
-	validNameCharRE   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*`)
-)
-
 // SampleID uniquely identifies a Sample
 type SampleID string
 
@@ -58,6 +53,8 @@ type MetricFamily struct {
 	TelegrafValueType telegraf.ValueType
 	// LabelSet is the label counts for all Samples.
 	LabelSet map[string]int
+	// Help is the HELP text from the field metadata.
+	Help string
 }
 
 type PrometheusClient struct {
@@ -306,7 +303,11 @@ func (p *PrometheusClient) Collect(ch chan<- prometheus.Metric) {
 				labelNames = append(labelNames, k)
 			}
 		}
-		desc := prometheus.NewDesc(name, "Telegraf collected metric", labelNames, nil)
+		help := family.Help
+		if help == "" {
+			help = "Telegraf collected metric"
+		}
+		desc := prometheus.NewDesc(name, help, labelNames, nil)
 
 		for _, sample := range family.Samples {
 			// Get labels for this sample; unset labels will be set to the
@@ -342,14 +343,6 @@ func (p *PrometheusClient) Collect(ch chan<- prometheus.Metric) {
 	}
 }
 
-func sanitize(value string) string {
-	return invalidNameCharRE.ReplaceAllString(value, "_")
-}
-
-func isValidTagName(tag string) bool {
-	return validNameCharRE.MatchString(tag)
-}
-
 func getPromValueType(tt telegraf.ValueType) prometheus.ValueType {
 	switch tt {
 	case telegraf.Counter:
@@ -380,7 +373,7 @@ func addSample(fam *MetricFamily, sample *Sample, sampleID SampleID) {
 	fam.Samples[sampleID] = sample
 }
 
-func (p *PrometheusClient) addMetricFamily(point telegraf.Metric, sample *Sample, mname string, sampleID SampleID) {
+func (p *PrometheusClient) addMetricFamily(point telegraf.Metric, sample *Sample, mname string, sampleID SampleID, help string) {
 	var fam *MetricFamily
 	var ok bool
 	if fam, ok = p.fam[mname]; !ok {
@@ -391,6 +384,9 @@ func (p *PrometheusClient) addMetricFamily(point telegraf.Metric, sample *Sample
 		}
 		p.fam[mname] = fam
 	}
+	if help != "" {
+		fam.Help = help
+	}
 
 	addSample(fam, sample, sampleID)
 }
@@ -421,8 +417,8 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 
 		labels := make(map[string]string)
 		for k, v := range tags {
-			tName := sanitize(k)
-			if !isValidTagName(tName) {
+			tName := serializer.Sanitize(k)
+			if !serializer.IsValidLabelName(tName) {
 				continue
 			}
 			labels[tName] = v
@@ -434,8 +430,8 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 			for fn, fv := range point.Fields() {
 				switch fv := fv.(type) {
 				case string:
-					tName := sanitize(fn)
-					if !isValidTagName(tName) {
+					tName := serializer.Sanitize(fn)
+					if !serializer.IsValidLabelName(tName) {
 						continue
 					}
 					labels[tName] = fv
@@ -482,13 +478,13 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 				Timestamp:    point.Time(),
 				Expiration:   now.Add(p.ExpirationInterval.Duration),
 			}
-			mname = sanitize(point.Name())
+			mname = serializer.Sanitize(point.Name())
 
-			if !isValidTagName(mname) {
+			if !serializer.IsValidMetricName(mname) {
 				continue
 			}
 
-			p.addMetricFamily(point, sample, mname, sampleID)
+			p.addMetricFamily(point, sample, mname, sampleID, serializer.HelpText(point, serializer.FieldKeys(point)...))
 
 		case telegraf.Histogram:
 			var mname string
@@ -528,13 +524,13 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 				Timestamp:      point.Time(),
 				Expiration:     now.Add(p.ExpirationInterval.Duration),
 			}
-			mname = sanitize(point.Name())
+			mname = serializer.Sanitize(point.Name())
 
-			if !isValidTagName(mname) {
+			if !serializer.IsValidMetricName(mname) {
 				continue
 			}
 
-			p.addMetricFamily(point, sample, mname, sampleID)
+			p.addMetricFamily(point, sample, mname, sampleID, serializer.HelpText(point, serializer.FieldKeys(point)...))
 
 		default:
 			for fn, fv := range point.Fields() {
@@ -564,24 +560,24 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 				switch point.Type() {
 				case telegraf.Counter:
 					if fn == "counter" {
-						mname = sanitize(point.Name())
+						mname = serializer.Sanitize(point.Name())
 					}
 				case telegraf.Gauge:
 					if fn == "gauge" {
-						mname = sanitize(point.Name())
+						mname = serializer.Sanitize(point.Name())
 					}
 				}
 				if mname == "" {
 					if fn == "value" {
-						mname = sanitize(point.Name())
+						mname = serializer.Sanitize(point.Name())
 					} else {
-						mname = sanitize(fmt.Sprintf("%s_%s", point.Name(), fn))
+						mname = serializer.Sanitize(fmt.Sprintf("%s_%s", point.Name(), fn))
 					}
 				}
-				if !isValidTagName(mname) {
+				if !serializer.IsValidMetricName(mname) {
 					continue
 				}
-				p.addMetricFamily(point, sample, mname, sampleID)
+				p.addMetricFamily(point, sample, mname, sampleID, serializer.HelpText(point, fn))
 
 			}
 		}
@@ -1,6 +1,7 @@
 package prometheus_client
 
 import (
+	"sort"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/influxdata/telegraf/metric"
 	prometheus_input "github.com/influxdata/telegraf/plugins/inputs/prometheus"
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -281,6 +283,39 @@ func TestWrite_Gauge(t *testing.T) {
 	}
 }
 
+func TestWrite_FieldMeta(t *testing.T) {
+	client := NewClient()
+
+	p1, err := metric.New(
+		"foo",
+		make(map[string]string),
+		map[string]interface{}{"idle": 1.0, "busy": 2.0, "other": 3.0},
+		time.Now())
+	require.NoError(t, err)
+	p1.SetFieldMeta("idle", telegraf.FieldMeta{Unit: "seconds", Description: "Idle time"})
+	p1.SetFieldMeta("busy", telegraf.FieldMeta{Description: "Busy time"})
+
+	err = client.Write([]telegraf.Metric{p1})
+	require.NoError(t, err)
+
+	require.Equal(t, "Idle time (seconds)", client.fam["foo_idle"].Help)
+	require.Equal(t, "Busy time", client.fam["foo_busy"].Help)
+	require.Equal(t, "", client.fam["foo_other"].Help)
+
+	ch := make(chan prometheus.Metric, 3)
+	client.Collect(ch)
+	close(ch)
+	var descs []string
+	for m := range ch {
+		descs = append(descs, m.Desc().String())
+	}
+	require.Len(t, descs, 3)
+	sort.Strings(descs)
+	require.Contains(t, descs[0], `help: "Busy time"`)
+	require.Contains(t, descs[1], `help: "Idle time (seconds)"`)
+	require.Contains(t, descs[2], `help: "Telegraf collected metric"`)
+}
+
 func TestWrite_Summary(t *testing.T) {
 	client := NewClient()
 
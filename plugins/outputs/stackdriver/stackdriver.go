@@ -15,6 +15,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"google.golang.org/api/option"
+	labelpb "google.golang.org/genproto/googleapis/api/label"
 	metricpb "google.golang.org/genproto/googleapis/api/metric"
 	monitoredrespb "google.golang.org/genproto/googleapis/api/monitoredres"
 	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
@@ -28,6 +29,9 @@ type Stackdriver struct {
 	ResourceLabels map[string]string `toml:"resource_labels"`
 
 	client *monitoring.MetricClient
+
+	// descriptors holds the metric types a descriptor was created for.
+	descriptors map[string]bool
 }
 
 const (
@@ -165,6 +169,12 @@ func (s *Stackdriver) Write(metrics []telegraf.Metric) error {
 				continue
 			}
 
+			metricType := path.Join("custom.googleapis.com", s.Namespace, m.Name(), f.Key)
+			metricLabels := getStackdriverLabels(m.TagList())
+			if meta, ok := m.GetFieldMeta(f.Key); ok {
+				s.createMetricDescriptor(ctx, metricType, metricKind, value, meta, metricLabels)
+			}
+
 			// Prepare an individual data point.
 			dataPoint := &monitoringpb.Point{
 				Interval: timeInterval,
@@ -174,8 +184,8 @@ func (s *Stackdriver) Write(metrics []telegraf.Metric) error {
 			// Prepare time series.
 			timeSeries := &monitoringpb.TimeSeries{
 				Metric: &metricpb.Metric{
-					Type:   path.Join("custom.googleapis.com", s.Namespace, m.Name(), f.Key),
-					Labels: getStackdriverLabels(m.TagList()),
+					Type:   metricType,
+					Labels: metricLabels,
 				},
 				MetricKind: metricKind,
 				Resource: &monitoredrespb.MonitoredResource{
@@ -239,6 +249,91 @@ func (s *Stackdriver) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+// createMetricDescriptor creates the metric descriptor of a metric type with
+// the unit and description of the field, this is done once per metric type.
+func (s *Stackdriver) createMetricDescriptor(
+	ctx context.Context,
+	metricType string,
+	metricKind metricpb.MetricDescriptor_MetricKind,
+	value *monitoringpb.TypedValue,
+	meta telegraf.FieldMeta,
+	metricLabels map[string]string,
+) {
+	if s.descriptors == nil {
+		s.descriptors = make(map[string]bool)
+	}
+	if s.descriptors[metricType] {
+		return
+	}
+	s.descriptors[metricType] = true
+
+	labels := make([]*labelpb.LabelDescriptor, 0, len(metricLabels))
+	for key := range metricLabels {
+		labels = append(labels, &labelpb.LabelDescriptor{
+			Key:       key,
+			ValueType: labelpb.LabelDescriptor_STRING,
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Key < labels[j].Key })
+
+	descriptorRequest := &monitoringpb.CreateMetricDescriptorRequest{
+		Name: monitoring.MetricProjectPath(s.Project),
+		MetricDescriptor: &metricpb.MetricDescriptor{
+			Type:        metricType,
+			Labels:      labels,
+			MetricKind:  metricKind,
+			ValueType:   getStackdriverValueType(value),
+			Unit:        getStackdriverUnit(meta.Unit),
+			Description: meta.Description,
+		},
+	}
+
+	_, err := s.client.CreateMetricDescriptor(ctx, descriptorRequest)
+	if err != nil {
+		log.Printf("E! [outputs.stackdriver] unable to create metric descriptor %q: %s", metricType, err)
+	}
+}
+
+// getStackdriverUnit converts a field unit to the UCUM unit used by
+// Stackdriver, units without a known conversion are added as annotation.
+func getStackdriverUnit(unit string) string {
+	switch unit {
+	case "":
+		return ""
+	case "percent":
+		return "%"
+	case "count":
+		return "1"
+	case "bytes":
+		return "By"
+	case "bits":
+		return "bit"
+	case "seconds":
+		return "s"
+	case "milliseconds":
+		return "ms"
+	case "microseconds":
+		return "us"
+	case "nanoseconds":
+		return "ns"
+	default:
+		return "{" + unit + "}"
+	}
+}
+
+func getStackdriverValueType(value *monitoringpb.TypedValue) metricpb.MetricDescriptor_ValueType {
+	switch value.Value.(type) {
+	case *monitoringpb.TypedValue_Int64Value:
+		return metricpb.MetricDescriptor_INT64
+	case *monitoringpb.TypedValue_DoubleValue:
+		return metricpb.MetricDescriptor_DOUBLE
+	case *monitoringpb.TypedValue_BoolValue:
+		return metricpb.MetricDescriptor_BOOL
+	default:
+		return metricpb.MetricDescriptor_VALUE_TYPE_UNSPECIFIED
+	}
+}
+
 func getStackdriverTimeInterval(
 	m metricpb.MetricDescriptor_MetricKind,
 	start int64,
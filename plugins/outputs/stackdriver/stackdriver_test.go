@@ -19,6 +19,7 @@ import (
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/api/option"
+	metricpb "google.golang.org/genproto/googleapis/api/metric"
 	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -57,6 +58,14 @@ func (s *mockMetricServer) CreateTimeSeries(ctx context.Context, req *monitoring
 	return s.resps[0].(*emptypb.Empty), nil
 }
 
+func (s *mockMetricServer) CreateMetricDescriptor(ctx context.Context, req *monitoringpb.CreateMetricDescriptorRequest) (*metricpb.MetricDescriptor, error) {
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return req.MetricDescriptor, nil
+}
+
 func TestMain(m *testing.M) {
 	serv := grpc.NewServer()
 	monitoringpb.RegisterMetricServiceServer(serv, &mockMetric)
@@ -361,6 +370,67 @@ func TestWriteBatchable(t *testing.T) {
 	})
 }
 
+func TestWriteMetricDescriptor(t *testing.T) {
+	expectedResponse := &emptypb.Empty{}
+	mockMetric.err = nil
+	mockMetric.reqs = nil
+	mockMetric.resps = append(mockMetric.resps[:0], expectedResponse)
+
+	c, err := monitoring.NewMetricClient(context.Background(), clientOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Stackdriver{
+		Project:   fmt.Sprintf("projects/%s", "[PROJECT]"),
+		Namespace: "test",
+		client:    c,
+	}
+
+	m1 := testutil.MustMetric("mem",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{
+			"used":         int64(42),
+			"used_percent": 4.2,
+		},
+		time.Unix(1, 0),
+	)
+	m1.SetFieldMeta("used", telegraf.FieldMeta{
+		Unit:        "bytes",
+		Description: "Used memory",
+	})
+	m2 := m1.Copy()
+	m2.SetTime(time.Unix(2, 0))
+
+	err = s.Connect()
+	require.NoError(t, err)
+	err = s.Write([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+
+	// The descriptor is created once, before the first time series request.
+	require.Len(t, mockMetric.reqs, 3)
+	request := mockMetric.reqs[0].(*monitoringpb.CreateMetricDescriptorRequest)
+	require.Equal(t, "projects/projects/[PROJECT]", request.Name)
+	require.Equal(t, "custom.googleapis.com/test/mem/used", request.MetricDescriptor.Type)
+	require.Equal(t, metricpb.MetricDescriptor_GAUGE, request.MetricDescriptor.MetricKind)
+	require.Equal(t, metricpb.MetricDescriptor_INT64, request.MetricDescriptor.ValueType)
+	require.Equal(t, "By", request.MetricDescriptor.Unit)
+	require.Equal(t, "Used memory", request.MetricDescriptor.Description)
+	require.Len(t, request.MetricDescriptor.Labels, 1)
+	require.Equal(t, "host", request.MetricDescriptor.Labels[0].Key)
+
+	require.IsType(t, &monitoringpb.CreateTimeSeriesRequest{}, mockMetric.reqs[1])
+	require.IsType(t, &monitoringpb.CreateTimeSeriesRequest{}, mockMetric.reqs[2])
+}
+
+func TestGetStackdriverUnit(t *testing.T) {
+	require.Equal(t, "", getStackdriverUnit(""))
+	require.Equal(t, "%", getStackdriverUnit("percent"))
+	require.Equal(t, "By", getStackdriverUnit("bytes"))
+	require.Equal(t, "s", getStackdriverUnit("seconds"))
+	require.Equal(t, "{requests}", getStackdriverUnit("requests"))
+}
+
 func TestWriteIgnoredErrors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -25,6 +25,7 @@ type Wavefront struct {
 	UseStrict       bool
 	SourceOverride  []string
 	StringToNumber  map[string][]map[string]float64
+	UnitTag         string
 
 	sender wavefront.Sender
 }
@@ -94,6 +95,10 @@ var sampleConfig = `
   ## whether to convert boolean values to numeric values, with false -> 0.0 and true -> 1.0. default is true
   #convert_bool = true
 
+  ## point tag to add the unit of the field to, if the field has one. Wavefront
+  ## has no place for field descriptions, these are not sent. default is no tag
+  #unit_tag = "unit"
+
   ## Define a mapping, namespaced by metric prefix, from string values to numeric values
   ##   deprecated in 1.9; use the enum processor plugin
   #[[outputs.wavefront.string_to_number.elasticsearch]]
@@ -198,7 +203,14 @@ func buildMetrics(m telegraf.Metric, w *Wavefront) []*MetricPoint {
 		}
 		metric.Value = metricValue
 
-		source, tags := buildTags(m.Tags(), w)
+		mTags := m.Tags()
+		if w.UnitTag != "" {
+			if meta, ok := m.GetFieldMeta(fieldName); ok && meta.Unit != "" {
+				mTags[w.UnitTag] = meta.Unit
+			}
+		}
+
+		source, tags := buildTags(mTags, w)
 		metric.Source = source
 		metric.Tags = tags
 
@@ -153,6 +153,40 @@ func TestBuildMetricsWithSimpleFields(t *testing.T) {
 
 }
 
+func TestBuildMetricsWithUnitTag(t *testing.T) {
+	w := defaultWavefront()
+	w.UnitTag = "unit"
+
+	m, _ := metric.New(
+		"mem",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"used": 42, "free": 24},
+		time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	m.SetFieldMeta("used", telegraf.FieldMeta{Unit: "bytes", Description: "Used memory"})
+
+	ml := buildMetrics(m, w)
+	if len(ml) != 2 {
+		t.Fatalf("expected 2 points, received %d", len(ml))
+	}
+	for _, point := range ml {
+		expected := map[string]string{}
+		if point.Metric == "testWF.mem.used" {
+			expected["unit"] = "bytes"
+		}
+		if !reflect.DeepEqual(expected, point.Tags) {
+			t.Errorf("\n%s expected\t%+v\nreceived\t%+v\n", point.Metric, expected, point.Tags)
+		}
+	}
+
+	w.UnitTag = ""
+	for _, point := range buildMetrics(m, w) {
+		if len(point.Tags) != 0 {
+			t.Errorf("\n%s expected no tags\nreceived\t%+v\n", point.Metric, point.Tags)
+		}
+	}
+}
+
 func TestBuildTags(t *testing.T) {
 
 	w := defaultWavefront()
@@ -0,0 +1,399 @@
+package json_v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	ErrNoMetric = errors.New("no metric in line")
+
+	utf8BOM = []byte("\xef\xbb\xbf")
+)
+
+// Parser reads metrics from JSON documents, using GJSON paths to select the
+// objects that metrics are created from.
+type Parser struct {
+	Configs []Config
+	// MetricName is the measurement name when a config does not set one.
+	MetricName  string
+	DefaultTags map[string]string
+
+	TimeFunc func() time.Time
+}
+
+// Config is a set of objects sharing the measurement name and the default
+// timestamp, which are read from the whole document.
+type Config struct {
+	MeasurementName     string `toml:"measurement_name"`
+	MeasurementNamePath string `toml:"measurement_name_path"`
+	TimestampPath       string `toml:"timestamp_path"`
+	TimestampFormat     string `toml:"timestamp_format"`
+	TimestampTimezone   string `toml:"timestamp_timezone"`
+
+	Objects []Object `toml:"object"`
+}
+
+// Object selects a JSON object, or an array of objects, each is turned into
+// one or more metrics.
+//
+// Nested objects are flattened, joining the keys with an underscore unless
+// DisablePrependKeys is set, and the elements of nested arrays are expanded
+// into a metric each.  Keys are matched after flattening.
+type Object struct {
+	Path              string `toml:"path"`
+	TimestampKey      string `toml:"timestamp_key"`
+	TimestampFormat   string `toml:"timestamp_format"`
+	TimestampTimezone string `toml:"timestamp_timezone"`
+
+	DisablePrependKeys bool     `toml:"disable_prepend_keys"`
+	IncludedKeys       []string `toml:"included_keys"`
+	ExcludedKeys       []string `toml:"excluded_keys"`
+	Tags               []string `toml:"tags"`
+	// Renames are the new names of tags and fields by key.
+	Renames map[string]string `toml:"renames"`
+	// Fields are the types of fields by key, one of "int", "uint", "float",
+	// "string" or "bool".  Other fields keep the type of the JSON value,
+	// with numbers as floats.
+	Fields map[string]string `toml:"fields"`
+}
+
+// Init checks the configuration, it must be called before the parser is
+// used.
+func (p *Parser) Init() error {
+	if len(p.Configs) == 0 {
+		return errors.New("no json_v2 configuration")
+	}
+
+	for _, cfg := range p.Configs {
+		for _, obj := range cfg.Objects {
+			for key, typ := range obj.Fields {
+				switch typ {
+				case "int", "uint", "float", "string", "bool":
+				default:
+					return fmt.Errorf("unknown type %q of field %q", typ, key)
+				}
+			}
+		}
+	}
+
+	if p.TimeFunc == nil {
+		p.TimeFunc = time.Now
+	}
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	buf = bytes.TrimSpace(buf)
+	buf = bytes.TrimPrefix(buf, utf8BOM)
+	if len(buf) == 0 {
+		return make([]telegraf.Metric, 0), nil
+	}
+
+	if !json.Valid(buf) {
+		return nil, errors.New("invalid JSON")
+	}
+
+	doc := gjson.Parse(string(buf))
+	now := p.TimeFunc()
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, cfg := range p.Configs {
+		name := cfg.MeasurementName
+		if cfg.MeasurementNamePath != "" {
+			if result := doc.Get(cfg.MeasurementNamePath); result.Exists() {
+				name = result.String()
+			}
+		}
+		if name == "" {
+			name = p.MetricName
+		}
+
+		tm := now
+		if cfg.TimestampPath != "" {
+			result := doc.Get(cfg.TimestampPath)
+			if !result.Exists() {
+				return nil, fmt.Errorf("timestamp path %q not found", cfg.TimestampPath)
+			}
+
+			var err error
+			tm, err = parseTime(result, cfg.TimestampFormat, cfg.TimestampTimezone)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, obj := range cfg.Objects {
+			result := doc
+			if obj.Path != "" {
+				result = doc.Get(obj.Path)
+			}
+			if !result.Exists() {
+				continue
+			}
+
+			if !result.IsObject() && !result.IsArray() {
+				return nil, fmt.Errorf("path %q must lead to a JSON object or array of objects, but lead to: %v",
+					obj.Path, result.Type)
+			}
+
+			for _, item := range result.Array() {
+				if !item.IsObject() {
+					return nil, fmt.Errorf("path %q must lead to a JSON object or array of objects, but lead to: %v",
+						obj.Path, item.Type)
+				}
+
+				for _, row := range obj.expand("", item) {
+					m, err := p.newMetric(name, tm, &obj, row)
+					if err != nil {
+						return nil, err
+					}
+					if m != nil {
+						metrics = append(metrics, m)
+					}
+				}
+			}
+		}
+	}
+	return metrics, nil
+}
+
+// newMetric creates the metric of a flattened row of the object, nil is
+// returned if the row has no field.
+func (p *Parser) newMetric(name string, tm time.Time, obj *Object, row map[string]gjson.Result) (telegraf.Metric, error) {
+	tags := make(map[string]string, len(p.DefaultTags))
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{}, len(row))
+
+	if obj.TimestampKey != "" {
+		result, ok := row[obj.TimestampKey]
+		if !ok {
+			return nil, fmt.Errorf("timestamp key %q not found", obj.TimestampKey)
+		}
+
+		var err error
+		tm, err = parseTime(result, obj.TimestampFormat, obj.TimestampTimezone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, result := range row {
+		if key == obj.TimestampKey {
+			continue
+		}
+
+		k := key
+		if rename, ok := obj.Renames[key]; ok {
+			k = rename
+		}
+
+		if contains(obj.Tags, key) {
+			tags[k] = result.String()
+			continue
+		}
+
+		value, err := convert(result, obj.Fields[key])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", key, err)
+		}
+		fields[k] = value
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return metric.New(name, tags, fields, tm)
+}
+
+// expand flattens the value into rows of values by key, each element of an
+// array adds the rows of the element.
+func (o *Object) expand(key string, result gjson.Result) []map[string]gjson.Result {
+	switch {
+	case result.IsObject():
+		rows := []map[string]gjson.Result{{}}
+		result.ForEach(func(k, v gjson.Result) bool {
+			name := k.String()
+			if key != "" && !o.DisablePrependKeys {
+				name = key + "_" + name
+			}
+			if contains(o.ExcludedKeys, name) {
+				return true
+			}
+			rows = product(rows, o.expand(name, v))
+			return true
+		})
+		return rows
+	case result.IsArray():
+		var rows []map[string]gjson.Result
+		result.ForEach(func(_, v gjson.Result) bool {
+			for _, row := range o.expand(key, v) {
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+			return true
+		})
+		if len(rows) == 0 {
+			return []map[string]gjson.Result{{}}
+		}
+		return rows
+	case result.Type == gjson.Null || !o.included(key):
+		return []map[string]gjson.Result{{}}
+	default:
+		return []map[string]gjson.Result{{key: result}}
+	}
+}
+
+// included returns true if the key is kept, either because there are no
+// included keys or it is listed as included, as a tag, as a field or as the
+// timestamp.
+func (o *Object) included(key string) bool {
+	if len(o.IncludedKeys) == 0 || key == o.TimestampKey {
+		return true
+	}
+	if _, ok := o.Fields[key]; ok {
+		return true
+	}
+	return contains(o.IncludedKeys, key) || contains(o.Tags, key)
+}
+
+// product returns the rows of every combination of the rows of a and b.
+func product(a, b []map[string]gjson.Result) []map[string]gjson.Result {
+	rows := make([]map[string]gjson.Result, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			row := make(map[string]gjson.Result, len(x)+len(y))
+			for k, v := range x {
+				row[k] = v
+			}
+			for k, v := range y {
+				row[k] = v
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func parseTime(result gjson.Result, format, timezone string) (time.Time, error) {
+	if format == "" {
+		format = "unix"
+	}
+
+	// The raw number is parsed to keep the precision of large integers.
+	value := result.Str
+	if result.Type == gjson.Number {
+		value = result.Raw
+	}
+	return internal.ParseTimestampWithLocation(value, format, timezone)
+}
+
+// convert returns the value of the result as the type, or as the type of
+// the JSON value if typ is empty.
+func convert(result gjson.Result, typ string) (interface{}, error) {
+	switch typ {
+	case "":
+		switch result.Type {
+		case gjson.Number:
+			return result.Num, nil
+		case gjson.True, gjson.False:
+			return result.Bool(), nil
+		default:
+			return result.String(), nil
+		}
+	case "int":
+		switch result.Type {
+		case gjson.Number:
+			if v, err := strconv.ParseInt(result.Raw, 10, 64); err == nil {
+				return v, nil
+			}
+			return int64(result.Num), nil
+		case gjson.True:
+			return int64(1), nil
+		case gjson.False:
+			return int64(0), nil
+		default:
+			return strconv.ParseInt(result.Str, 10, 64)
+		}
+	case "uint":
+		switch result.Type {
+		case gjson.Number:
+			if v, err := strconv.ParseUint(result.Raw, 10, 64); err == nil {
+				return v, nil
+			}
+			if result.Num < 0 {
+				return nil, fmt.Errorf("negative value %v is not an uint", result.Num)
+			}
+			return uint64(result.Num), nil
+		case gjson.True:
+			return uint64(1), nil
+		case gjson.False:
+			return uint64(0), nil
+		default:
+			return strconv.ParseUint(result.Str, 10, 64)
+		}
+	case "float":
+		switch result.Type {
+		case gjson.Number:
+			return result.Num, nil
+		case gjson.True:
+			return float64(1), nil
+		case gjson.False:
+			return float64(0), nil
+		default:
+			return strconv.ParseFloat(result.Str, 64)
+		}
+	case "string":
+		if result.Type == gjson.Number {
+			return result.Raw, nil
+		}
+		return result.String(), nil
+	case "bool":
+		switch result.Type {
+		case gjson.Number:
+			return result.Num != 0, nil
+		case gjson.True, gjson.False:
+			return result.Bool(), nil
+		default:
+			return strconv.ParseBool(result.Str)
+		}
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, ErrNoMetric
+	}
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
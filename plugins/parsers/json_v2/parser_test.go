@@ -0,0 +1,370 @@
+package json_v2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const library = `
+{
+    "name": "books",
+    "updated": "2021-03-04T05:06:07Z",
+    "library": {
+        "city": "Lisbon",
+        "open": true
+    },
+    "books": [
+        {
+            "title": "The Lord Of The Rings",
+            "author": "Tolkien",
+            "id": "12",
+            "published": 1954,
+            "rating": 4.5,
+            "chapters": [
+                {"number": 1, "pages": 20},
+                {"number": 2, "pages": 31}
+            ]
+        },
+        {
+            "title": "Dune",
+            "author": "Herbert",
+            "id": "7",
+            "published": 1965,
+            "rating": 4.25,
+            "chapters": []
+        }
+    ]
+}
+`
+
+func now() time.Time {
+	return time.Unix(42, 0)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		configs  []Config
+		expected []telegraf.Metric
+	}{
+		{
+			name: "object",
+			configs: []Config{
+				{
+					Objects: []Object{
+						{Path: "library"},
+					},
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"json_v2",
+					map[string]string{},
+					map[string]interface{}{
+						"city": "Lisbon",
+						"open": true,
+					},
+					now(),
+				),
+			},
+		},
+		{
+			name: "array of objects with tags and types",
+			configs: []Config{
+				{
+					MeasurementNamePath: "name",
+					TimestampPath:       "updated",
+					TimestampFormat:     "2006-01-02T15:04:05Z",
+					Objects: []Object{
+						{
+							Path:         "books",
+							Tags:         []string{"author"},
+							ExcludedKeys: []string{"chapters"},
+							Renames: map[string]string{
+								"title": "name",
+							},
+							Fields: map[string]string{
+								"id":        "uint",
+								"published": "int",
+							},
+						},
+					},
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"books",
+					map[string]string{
+						"author": "Tolkien",
+					},
+					map[string]interface{}{
+						"name":      "The Lord Of The Rings",
+						"id":        uint64(12),
+						"published": int64(1954),
+						"rating":    4.5,
+					},
+					time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+				),
+				testutil.MustMetric(
+					"books",
+					map[string]string{
+						"author": "Herbert",
+					},
+					map[string]interface{}{
+						"name":      "Dune",
+						"id":        uint64(7),
+						"published": int64(1965),
+						"rating":    4.25,
+					},
+					time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name: "nested arrays",
+			configs: []Config{
+				{
+					MeasurementName: "chapters",
+					Objects: []Object{
+						{
+							Path:         "books",
+							Tags:         []string{"title"},
+							IncludedKeys: []string{"chapters_number", "chapters_pages"},
+							Fields: map[string]string{
+								"chapters_number": "int",
+								"chapters_pages":  "int",
+							},
+						},
+					},
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"chapters",
+					map[string]string{
+						"title": "The Lord Of The Rings",
+					},
+					map[string]interface{}{
+						"chapters_number": int64(1),
+						"chapters_pages":  int64(20),
+					},
+					now(),
+				),
+				testutil.MustMetric(
+					"chapters",
+					map[string]string{
+						"title": "The Lord Of The Rings",
+					},
+					map[string]interface{}{
+						"chapters_number": int64(2),
+						"chapters_pages":  int64(31),
+					},
+					now(),
+				),
+			},
+		},
+		{
+			name: "disable prepend keys",
+			configs: []Config{
+				{
+					Objects: []Object{
+						{
+							Path:               "books.0",
+							DisablePrependKeys: true,
+							IncludedKeys:       []string{"number", "pages"},
+						},
+					},
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"json_v2",
+					map[string]string{},
+					map[string]interface{}{
+						"number": 1.0,
+						"pages":  20.0,
+					},
+					now(),
+				),
+				testutil.MustMetric(
+					"json_v2",
+					map[string]string{},
+					map[string]interface{}{
+						"number": 2.0,
+						"pages":  31.0,
+					},
+					now(),
+				),
+			},
+		},
+		{
+			name: "multiple configs",
+			configs: []Config{
+				{
+					MeasurementName: "library",
+					Objects: []Object{
+						{Path: "library", Tags: []string{"city"}},
+					},
+				},
+				{
+					MeasurementName: "missing",
+					Objects: []Object{
+						{Path: "shelves"},
+					},
+				},
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"library",
+					map[string]string{
+						"city": "Lisbon",
+					},
+					map[string]interface{}{
+						"open": true,
+					},
+					now(),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				Configs:    tt.configs,
+				MetricName: "json_v2",
+				TimeFunc:   now,
+			}
+			require.NoError(t, parser.Init())
+
+			metrics, err := parser.Parse([]byte(library))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, metrics)
+		})
+	}
+}
+
+func TestTimestampKey(t *testing.T) {
+	parser := &Parser{
+		Configs: []Config{
+			{
+				Objects: []Object{
+					{
+						TimestampKey:    "time",
+						TimestampFormat: "unix_ms",
+						Tags:            []string{"host"},
+					},
+				},
+			},
+		},
+		MetricName: "json_v2",
+		DefaultTags: map[string]string{
+			"host":   "default",
+			"region": "eu",
+		},
+	}
+	require.NoError(t, parser.Init())
+
+	metrics, err := parser.Parse([]byte(`[{"host": "a", "time": 1600000000123, "load": 0.5}]`))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"json_v2",
+			map[string]string{
+				"host":   "a",
+				"region": "eu",
+			},
+			map[string]interface{}{
+				"load": 0.5,
+			},
+			time.Unix(1600000000, 123000000),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name   string
+		object Object
+		input  string
+	}{
+		{
+			name:   "invalid json",
+			object: Object{},
+			input:  `{"a": `,
+		},
+		{
+			name:   "path to value",
+			object: Object{Path: "a"},
+			input:  `{"a": 1}`,
+		},
+		{
+			name:   "array of values",
+			object: Object{Path: "a"},
+			input:  `{"a": [1, 2]}`,
+		},
+		{
+			name:   "missing timestamp key",
+			object: Object{TimestampKey: "time"},
+			input:  `{"a": 1}`,
+		},
+		{
+			name: "invalid int",
+			object: Object{
+				Fields: map[string]string{"a": "int"},
+			},
+			input: `{"a": "one"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				Configs: []Config{
+					{Objects: []Object{tt.object}},
+				},
+			}
+			require.NoError(t, parser.Init())
+
+			_, err := parser.Parse([]byte(tt.input))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestInitError(t *testing.T) {
+	parser := &Parser{
+		Configs: []Config{
+			{
+				Objects: []Object{
+					{Fields: map[string]string{"a": "integer"}},
+				},
+			},
+		},
+	}
+	require.Error(t, parser.Init())
+}
+
+func TestParseLine(t *testing.T) {
+	parser := &Parser{
+		Configs: []Config{
+			{Objects: []Object{{}}},
+		},
+		MetricName: "json_v2",
+		TimeFunc:   now,
+	}
+	require.NoError(t, parser.Init())
+
+	m, err := parser.ParseLine(`{"a": 1}`)
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		testutil.MustMetric("json_v2", map[string]string{}, map[string]interface{}{"a": 1.0}, now()),
+		m)
+
+	_, err = parser.ParseLine(`{"a": null}`)
+	require.Equal(t, ErrNoMetric, err)
+}
@@ -0,0 +1,222 @@
+package prometheus
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func now() time.Time {
+	return time.Unix(42, 0)
+}
+
+func mustMetric(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+	tp telegraf.ValueType,
+) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, tm, tp)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []telegraf.Metric
+	}{
+		{
+			name: "counter",
+			input: `# HELP get_token_fail_count Counter of failed Token() requests
+# TYPE get_token_fail_count counter
+get_token_fail_count 2
+`,
+			expected: []telegraf.Metric{
+				mustMetric("get_token_fail_count",
+					map[string]string{},
+					map[string]interface{}{"counter": 2.0},
+					now(),
+					telegraf.Counter,
+				),
+			},
+		},
+		{
+			name: "gauge with labels",
+			input: `# TYPE go_goroutines gauge
+go_goroutines{instance="a"} 15
+go_goroutines{instance="b"} 16
+`,
+			expected: []telegraf.Metric{
+				mustMetric("go_goroutines",
+					map[string]string{"instance": "a"},
+					map[string]interface{}{"gauge": 15.0},
+					now(),
+					telegraf.Gauge,
+				),
+				mustMetric("go_goroutines",
+					map[string]string{"instance": "b"},
+					map[string]interface{}{"gauge": 16.0},
+					now(),
+					telegraf.Gauge,
+				),
+			},
+		},
+		{
+			name:  "untyped with timestamp",
+			input: "queue_length 3 1490802350000\n",
+			expected: []telegraf.Metric{
+				mustMetric("queue_length",
+					map[string]string{},
+					map[string]interface{}{"value": 3.0},
+					time.Unix(1490802350, 0),
+					telegraf.Untyped,
+				),
+			},
+		},
+		{
+			name: "summary",
+			input: `# TYPE http_request_duration_seconds summary
+http_request_duration_seconds{handler="metrics",quantile="0.5"} 0.0012
+http_request_duration_seconds{handler="metrics",quantile="0.9"} 0.0031
+http_request_duration_seconds_sum{handler="metrics"} 1.5
+http_request_duration_seconds_count{handler="metrics"} 100
+`,
+			expected: []telegraf.Metric{
+				mustMetric("http_request_duration_seconds",
+					map[string]string{"handler": "metrics"},
+					map[string]interface{}{
+						"0.5":   0.0012,
+						"0.9":   0.0031,
+						"sum":   1.5,
+						"count": 100.0,
+					},
+					now(),
+					telegraf.Summary,
+				),
+			},
+		},
+		{
+			name: "histogram",
+			input: `# TYPE request_size_bytes histogram
+request_size_bytes_bucket{le="100"} 3
+request_size_bytes_bucket{le="1000"} 5
+request_size_bytes_bucket{le="+Inf"} 6
+request_size_bytes_sum 4200
+request_size_bytes_count 6
+`,
+			expected: []telegraf.Metric{
+				mustMetric("request_size_bytes",
+					map[string]string{},
+					map[string]interface{}{
+						"100":   3.0,
+						"1000":  5.0,
+						"+Inf":  6.0,
+						"sum":   4200.0,
+						"count": 6.0,
+					},
+					now(),
+					telegraf.Histogram,
+				),
+			},
+		},
+		{
+			name: "nan is ignored",
+			input: `# TYPE temperature gauge
+temperature NaN
+`,
+			expected: []telegraf.Metric{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser(nil)
+			parser.Now = now
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+			for i := range actual {
+				require.Equal(t, tt.expected[i].Type(), actual[i].Type())
+			}
+		})
+	}
+}
+
+func TestParseHelp(t *testing.T) {
+	parser := NewParser(nil)
+	actual, err := parser.Parse([]byte(`# HELP go_goroutines Number of goroutines that currently exist.
+# TYPE go_goroutines gauge
+go_goroutines 15
+`))
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+
+	meta, ok := actual[0].GetFieldMeta("gauge")
+	require.True(t, ok)
+	require.Equal(t, "Number of goroutines that currently exist.", meta.Description)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := NewParser(nil)
+	_, err := parser.Parse([]byte("cpu,host=foo usage_idle=99\n"))
+	require.Error(t, err)
+}
+
+func TestParseLine(t *testing.T) {
+	parser := NewParser(map[string]string{"host": "a", "instance": "default"})
+	parser.Now = now
+	actual, err := parser.ParseLine(`queue_length{instance="b"} 3`)
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		mustMetric("queue_length",
+			map[string]string{"host": "a", "instance": "b"},
+			map[string]interface{}{"value": 3.0},
+			now(),
+			telegraf.Untyped,
+		), actual)
+
+	_, err = parser.ParseLine("# TYPE queue_length gauge")
+	require.Equal(t, ErrNoMetric, err)
+}
+
+func TestParseProtobuf(t *testing.T) {
+	mf := &dto.MetricFamily{
+		Name: proto.String("go_goroutines"),
+		Type: dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{
+			{Gauge: &dto.Gauge{Value: proto.Float64(15)}},
+		},
+	}
+	var buf bytes.Buffer
+	_, err := pbutil.WriteDelimited(&buf, mf)
+	require.NoError(t, err)
+
+	parser := NewParser(nil)
+	parser.Now = now
+	parser.Header = http.Header{}
+	parser.Header.Set("Content-Type",
+		"application/vnd.google.protobuf; encoding=delimited; proto=io.prometheus.client.MetricFamily")
+	actual, err := parser.Parse(buf.Bytes())
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		mustMetric("go_goroutines",
+			map[string]string{},
+			map[string]interface{}{"gauge": 15.0},
+			now(),
+			telegraf.Gauge,
+		),
+	}, actual)
+}
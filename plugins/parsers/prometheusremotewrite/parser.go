@@ -0,0 +1,93 @@
+package prometheusremotewrite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/prompb"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Parser decodes the body of Prometheus remote write requests into metrics.
+type Parser struct {
+	DefaultTags map[string]string
+}
+
+// NewParser creates a parser.
+func NewParser(defaultTags map[string]string) *Parser {
+	return &Parser{
+		DefaultTags: defaultTags,
+	}
+}
+
+// Parse returns a metric for each sample of the write request, named after
+// the series with its other labels as tags and the value in the value
+// field, as the prometheus input does for untyped metrics.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	data, err := snappy.Decode(nil, buf)
+	if err != nil {
+		return nil, fmt.Errorf("decoding snappy failed: %s", err)
+	}
+
+	var req prompb.WriteRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("reading write request failed: %s", err)
+	}
+
+	var metrics []telegraf.Metric
+	for _, ts := range req.Timeseries {
+		var name string
+		tags := make(map[string]string, len(p.DefaultTags)+len(ts.Labels))
+		for k, v := range p.DefaultTags {
+			tags[k] = v
+		}
+		for _, l := range ts.Labels {
+			if l.Name == "__name__" {
+				name = l.Value
+				continue
+			}
+			tags[l.Name] = l.Value
+		}
+		if name == "" {
+			return nil, errors.New("series without __name__ label")
+		}
+
+		for _, s := range ts.Samples {
+			// NaN is used by Prometheus to mark stale series
+			if math.IsNaN(s.Value) {
+				continue
+			}
+			fields := map[string]interface{}{"value": s.Value}
+			t := time.Unix(0, s.Timestamp*int64(time.Millisecond))
+			m, err := metric.New(name, tags, fields, t, telegraf.Untyped)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) != 1 {
+		return nil, errors.New("line contains multiple metrics")
+	}
+
+	return metrics[0], nil
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse and ParseLine.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
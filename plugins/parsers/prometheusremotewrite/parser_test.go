@@ -0,0 +1,88 @@
+package prometheusremotewrite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/prompb"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(t *testing.T, req *prompb.WriteRequest) []byte {
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+	return snappy.Encode(nil, data)
+}
+
+func TestParse(t *testing.T) {
+	req := &prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels: []*prompb.Label{
+					{Name: "__name__", Value: "go_goroutines"},
+					{Name: "instance", Value: "localhost:9090"},
+					{Name: "job", Value: "prometheus"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: 15, Timestamp: 1490802350000},
+					{Value: 16, Timestamp: 1490802360000},
+				},
+			},
+			{
+				Labels: []*prompb.Label{
+					{Name: "__name__", Value: "up"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: 1, Timestamp: 1490802350000},
+					{Value: math.NaN(), Timestamp: 1490802360000},
+				},
+			},
+		},
+	}
+
+	parser := NewParser(map[string]string{"host": "a", "job": "default"})
+	actual, err := parser.Parse(encode(t, req))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("go_goroutines",
+			map[string]string{"host": "a", "instance": "localhost:9090", "job": "prometheus"},
+			map[string]interface{}{"value": 15.0},
+			time.Unix(1490802350, 0),
+		),
+		testutil.MustMetric("go_goroutines",
+			map[string]string{"host": "a", "instance": "localhost:9090", "job": "prometheus"},
+			map[string]interface{}{"value": 16.0},
+			time.Unix(1490802360, 0),
+		),
+		testutil.MustMetric("up",
+			map[string]string{"host": "a", "job": "default"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(1490802350, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := NewParser(nil)
+
+	_, err := parser.Parse([]byte("cpu value=42"))
+	require.Error(t, err)
+
+	req := &prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels:  []*prompb.Label{{Name: "job", Value: "prometheus"}},
+				Samples: []*prompb.Sample{{Value: 1, Timestamp: 1490802350000}},
+			},
+		},
+	}
+	_, err = parser.Parse(encode(t, req))
+	require.Error(t, err)
+}
@@ -12,10 +12,14 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
 	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheusremotewrite"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 	"github.com/influxdata/telegraf/plugins/parsers/wavefront"
+	"github.com/influxdata/telegraf/plugins/parsers/xml"
 )
 
 type ParserFunc func() (Parser, error)
@@ -141,6 +145,18 @@ type Config struct {
 	CSVTimestampColumn   string   `toml:"csv_timestamp_column"`
 	CSVTimestampFormat   string   `toml:"csv_timestamp_format"`
 	CSVTrimSpace         bool     `toml:"csv_trim_space"`
+
+	// XPath expressions of the xml data format
+	XMLMetricSelection string            `toml:"xml_metric_selection"`
+	XMLMetricName      string            `toml:"xml_metric_name"`
+	XMLTimestamp       string            `toml:"xml_timestamp"`
+	XMLTimestampFormat string            `toml:"xml_timestamp_format"`
+	XMLTags            map[string]string `toml:"xml_tags"`
+	XMLFields          map[string]string `toml:"xml_fields"`
+	XMLFieldsInt       map[string]string `toml:"xml_fields_int"`
+
+	// GJSON paths of the json_v2 data format
+	JSONV2Config []json_v2.Config `toml:"json_v2"`
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -209,6 +225,14 @@ func NewParser(config *Config) (Parser, error) {
 			config.DefaultTags)
 	case "logfmt":
 		parser, err = NewLogFmtParser(config.MetricName, config.DefaultTags)
+	case "prometheus":
+		parser, err = NewPrometheusParser(config.DefaultTags)
+	case "prometheusremotewrite":
+		parser, err = NewPrometheusRemoteWriteParser(config.DefaultTags)
+	case "xml":
+		parser, err = newXMLParser(config)
+	case "json_v2":
+		parser, err = newJSONV2Parser(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -316,6 +340,34 @@ func newGrokParser(metricName string,
 	return &parser, err
 }
 
+func newXMLParser(config *Config) (Parser, error) {
+	parser := &xml.Parser{
+		MetricSelection:   config.XMLMetricSelection,
+		MetricName:        config.XMLMetricName,
+		MetricDefaultName: config.MetricName,
+		Timestamp:         config.XMLTimestamp,
+		TimestampFormat:   config.XMLTimestampFormat,
+		Tags:              config.XMLTags,
+		Fields:            config.XMLFields,
+		FieldsInt:         config.XMLFieldsInt,
+		DefaultTags:       config.DefaultTags,
+	}
+
+	err := parser.Compile()
+	return parser, err
+}
+
+func newJSONV2Parser(config *Config) (Parser, error) {
+	parser := &json_v2.Parser{
+		Configs:     config.JSONV2Config,
+		MetricName:  config.MetricName,
+		DefaultTags: config.DefaultTags,
+	}
+
+	err := parser.Init()
+	return parser, err
+}
+
 func NewJSONParser(
 	metricName string,
 	tagKeys []string,
@@ -397,6 +449,17 @@ func NewLogFmtParser(metricName string, defaultTags map[string]string) (Parser,
 	return logfmt.NewParser(metricName, defaultTags), nil
 }
 
+// NewPrometheusParser returns a parser of the Prometheus text format.
+func NewPrometheusParser(defaultTags map[string]string) (Parser, error) {
+	return prometheus.NewParser(defaultTags), nil
+}
+
+// NewPrometheusRemoteWriteParser returns a parser of Prometheus remote write
+// requests.
+func NewPrometheusRemoteWriteParser(defaultTags map[string]string) (Parser, error) {
+	return prometheusremotewrite.NewParser(defaultTags), nil
+}
+
 func NewWavefrontParser(defaultTags map[string]string) (Parser, error) {
 	return wavefront.NewWavefrontParser(defaultTags), nil
 }
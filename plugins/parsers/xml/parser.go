@@ -0,0 +1,270 @@
+package xml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var (
+	ErrNoMetric = errors.New("no metric in line")
+)
+
+// Parser reads metrics from XML documents, using XPath expressions to select
+// the nodes of the metrics and the values of their name, time, tags and
+// fields.
+type Parser struct {
+	// MetricSelection selects the nodes a metric is created for, the other
+	// expressions are evaluated relative to these nodes.
+	MetricSelection string
+	// MetricName is the expression of the measurement name, if empty
+	// MetricDefaultName is used.
+	MetricName        string
+	MetricDefaultName string
+	// Timestamp is the expression of the metric time, if empty the current
+	// time is used.
+	Timestamp       string
+	TimestampFormat string
+	// Tags are the expressions of the tags by tag key.
+	Tags map[string]string
+	// Fields are the expressions of the fields by field key, the type of
+	// the field is the type of the result of the expression.
+	Fields map[string]string
+	// FieldsInt are the expressions of the integer fields by field key.
+	FieldsInt   map[string]string
+	DefaultTags map[string]string
+
+	TimeFunc func() time.Time
+
+	selection *xpath.Expr
+	name      *xpath.Expr
+	timestamp *xpath.Expr
+	tags      []namedExpr
+	fields    []namedExpr
+	fieldsInt []namedExpr
+}
+
+type namedExpr struct {
+	key  string
+	expr *xpath.Expr
+}
+
+// Compile compiles the XPath expressions, it must be called before the
+// parser is used.
+func (p *Parser) Compile() error {
+	var err error
+	selection := p.MetricSelection
+	if selection == "" {
+		selection = "/"
+	}
+	p.selection, err = xpath.Compile(selection)
+	if err != nil {
+		return fmt.Errorf("invalid metric selection %q: %v", selection, err)
+	}
+
+	if p.MetricName != "" {
+		p.name, err = xpath.Compile(p.MetricName)
+		if err != nil {
+			return fmt.Errorf("invalid metric name %q: %v", p.MetricName, err)
+		}
+	}
+
+	if p.Timestamp != "" {
+		p.timestamp, err = xpath.Compile(p.Timestamp)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp %q: %v", p.Timestamp, err)
+		}
+	}
+	if p.TimestampFormat == "" {
+		p.TimestampFormat = "unix"
+	}
+
+	if p.tags, err = compileAll(p.Tags); err != nil {
+		return fmt.Errorf("invalid tag %v", err)
+	}
+	if p.fields, err = compileAll(p.Fields); err != nil {
+		return fmt.Errorf("invalid field %v", err)
+	}
+	if p.fieldsInt, err = compileAll(p.FieldsInt); err != nil {
+		return fmt.Errorf("invalid field %v", err)
+	}
+
+	if p.TimeFunc == nil {
+		p.TimeFunc = time.Now
+	}
+	return nil
+}
+
+// compileAll compiles the expressions, sorted by key.
+func compileAll(exprs map[string]string) ([]namedExpr, error) {
+	compiled := make([]namedExpr, 0, len(exprs))
+	for key, expr := range exprs {
+		e, err := xpath.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", key, err)
+		}
+		compiled = append(compiled, namedExpr{key: key, expr: e})
+	}
+	sort.Slice(compiled, func(i, j int) bool {
+		return compiled[i].key < compiled[j].key
+	})
+	return compiled, nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	doc, err := xmlquery.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	now := p.TimeFunc()
+	metrics := make([]telegraf.Metric, 0)
+	// The navigators of the selected nodes keep the document as their root,
+	// so that absolute paths can be used in the other expressions.
+	var nodes []xpath.NodeNavigator
+	iter := p.selection.Select(xmlquery.CreateXPathNavigator(doc))
+	for iter.MoveNext() {
+		nodes = append(nodes, iter.Current().Copy())
+	}
+	for _, node := range nodes {
+		m, err := p.parseNode(node, now)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+// parseNode returns the metric of the selected node, or nil if it has no
+// fields.
+func (p *Parser) parseNode(node xpath.NodeNavigator, now time.Time) (telegraf.Metric, error) {
+	name := p.MetricDefaultName
+	if p.name != nil {
+		if v, ok := evaluate(p.name, node); ok {
+			name = toString(v)
+		}
+	}
+
+	tm := now
+	if p.timestamp != nil {
+		v, ok := evaluate(p.timestamp, node)
+		if !ok {
+			return nil, fmt.Errorf("timestamp %q not found", p.Timestamp)
+		}
+		if s, ok := v.(string); ok {
+			v = strings.TrimSpace(s)
+		}
+		var err error
+		tm, err = internal.ParseTimestamp(v, p.TimestampFormat)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tags := make(map[string]string, len(p.DefaultTags)+len(p.tags))
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for _, tag := range p.tags {
+		if v, ok := evaluate(tag.expr, node); ok {
+			tags[tag.key] = toString(v)
+		}
+	}
+
+	fields := make(map[string]interface{}, len(p.fields)+len(p.fieldsInt))
+	for _, field := range p.fields {
+		if v, ok := evaluate(field.expr, node); ok {
+			fields[field.key] = v
+		}
+	}
+	for _, field := range p.fieldsInt {
+		v, ok := evaluate(field.expr, node)
+		if !ok {
+			continue
+		}
+		i, err := toInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", field.key, err)
+		}
+		fields[field.key] = i
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return metric.New(name, tags, fields, tm)
+}
+
+// evaluate returns the result of the expression on the node, a float64,
+// bool or string.  A node set is the text of its first node, it is false if
+// the node set is empty.
+func evaluate(expr *xpath.Expr, node xpath.NodeNavigator) (interface{}, bool) {
+	switch v := expr.Evaluate(node.Copy()).(type) {
+	case *xpath.NodeIterator:
+		if !v.MoveNext() {
+			return nil, false
+		}
+		return v.Current().Value(), true
+	case float64, bool, string:
+		return v, true
+	}
+	return nil, false
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func toInt(v interface{}) (int64, error) {
+	switch v := v.(type) {
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	case float64:
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported type %T", v)
+}
+
+// ParseLine parses a line containing a whole XML document and returns its
+// first metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, ErrNoMetric
+	}
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
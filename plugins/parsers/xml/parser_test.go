@@ -0,0 +1,239 @@
+package xml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const sensors = `<?xml version="1.0"?>
+<Gateway>
+  <Name>Main Gateway</Name>
+  <Timestamp>2020-08-01T15:04:03Z</Timestamp>
+  <Sequence>12</Sequence>
+  <Status>ok</Status>
+  <Bus>
+    <Sensor name="Sensor Facility A">
+      <Variable temperature="20.0"/>
+      <Variable power="123.4"/>
+      <Variable frequency="49.78"/>
+      <Variable consumers="3"/>
+      <Mode>busy</Mode>
+    </Sensor>
+    <Sensor name="Sensor Facility B">
+      <Variable temperature="23.1"/>
+      <Variable power="14.3"/>
+      <Variable frequency="49.78"/>
+      <Variable consumers="1"/>
+      <Mode>standby</Mode>
+    </Sensor>
+  </Bus>
+</Gateway>
+`
+
+func now() time.Time {
+	return time.Unix(42, 0)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   *Parser
+		input    string
+		expected []telegraf.Metric
+	}{
+		{
+			name: "document",
+			parser: &Parser{
+				MetricDefaultName: "xml",
+				Tags: map[string]string{
+					"gateway": "/Gateway/Name",
+				},
+				Fields: map[string]string{
+					"status": "/Gateway/Status",
+					"ok":     "/Gateway/Status = 'ok'",
+				},
+				FieldsInt: map[string]string{
+					"sequence": "/Gateway/Sequence",
+				},
+			},
+			input: sensors,
+			expected: []telegraf.Metric{
+				testutil.MustMetric("xml",
+					map[string]string{"gateway": "Main Gateway"},
+					map[string]interface{}{
+						"status":   "ok",
+						"ok":       true,
+						"sequence": int64(12),
+					},
+					now(),
+				),
+			},
+		},
+		{
+			name: "metric selection",
+			parser: &Parser{
+				MetricSelection: "/Gateway/Bus/Sensor",
+				MetricName:      "string('sensors')",
+				Timestamp:       "/Gateway/Timestamp",
+				TimestampFormat: "2006-01-02T15:04:05Z",
+				Tags: map[string]string{
+					"name": "substring-after(@name, 'Facility ')",
+				},
+				Fields: map[string]string{
+					"temperature": "number(Variable/@temperature)",
+					"power":       "number(Variable/@power)",
+					"mode":        "Mode",
+					"busy":        "Mode = 'busy'",
+				},
+				FieldsInt: map[string]string{
+					"consumers": "Variable/@consumers",
+				},
+			},
+			input: sensors,
+			expected: []telegraf.Metric{
+				testutil.MustMetric("sensors",
+					map[string]string{"name": "A"},
+					map[string]interface{}{
+						"temperature": 20.0,
+						"power":       123.4,
+						"mode":        "busy",
+						"busy":        true,
+						"consumers":   int64(3),
+					},
+					time.Date(2020, 8, 1, 15, 4, 3, 0, time.UTC),
+				),
+				testutil.MustMetric("sensors",
+					map[string]string{"name": "B"},
+					map[string]interface{}{
+						"temperature": 23.1,
+						"power":       14.3,
+						"mode":        "standby",
+						"busy":        false,
+						"consumers":   int64(1),
+					},
+					time.Date(2020, 8, 1, 15, 4, 3, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name: "unix timestamp and missing nodes",
+			parser: &Parser{
+				MetricSelection: "//Point",
+				MetricName:      "../@name",
+				Timestamp:       "@time",
+				Fields: map[string]string{
+					"value": "number(.)",
+					"unit":  "@unit",
+				},
+			},
+			input: `<Series name="load"><Point time="1596294243">1.5</Point><Point time="1596294253" unit="load">2.5</Point></Series>`,
+			expected: []telegraf.Metric{
+				testutil.MustMetric("load",
+					map[string]string{},
+					map[string]interface{}{"value": 1.5},
+					time.Unix(1596294243, 0),
+				),
+				testutil.MustMetric("load",
+					map[string]string{},
+					map[string]interface{}{"value": 2.5, "unit": "load"},
+					time.Unix(1596294253, 0),
+				),
+			},
+		},
+		{
+			name: "no fields",
+			parser: &Parser{
+				MetricDefaultName: "xml",
+				Fields: map[string]string{
+					"missing": "/Gateway/Missing",
+				},
+			},
+			input:    sensors,
+			expected: []telegraf.Metric{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parser.TimeFunc = now
+			require.NoError(t, tt.parser.Compile())
+			actual, err := tt.parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestDefaultTags(t *testing.T) {
+	parser := &Parser{
+		MetricDefaultName: "xml",
+		Tags: map[string]string{
+			"gateway": "/Gateway/Name",
+		},
+		FieldsInt: map[string]string{
+			"sequence": "/Gateway/Sequence",
+		},
+		TimeFunc: now,
+	}
+	require.NoError(t, parser.Compile())
+	parser.SetDefaultTags(map[string]string{"host": "example.org", "gateway": "default"})
+
+	actual, err := parser.ParseLine(sensors)
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		testutil.MustMetric("xml",
+			map[string]string{"host": "example.org", "gateway": "Main Gateway"},
+			map[string]interface{}{"sequence": int64(12)},
+			now(),
+		), actual)
+}
+
+func TestCompileError(t *testing.T) {
+	parser := &Parser{
+		Fields: map[string]string{
+			"value": "number(",
+		},
+	}
+	require.Error(t, parser.Compile())
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser *Parser
+		input  string
+	}{
+		{
+			name: "invalid xml",
+			parser: &Parser{
+				Fields: map[string]string{"value": "/a"},
+			},
+			input: "<a>",
+		},
+		{
+			name: "invalid integer",
+			parser: &Parser{
+				FieldsInt: map[string]string{"value": "/a"},
+			},
+			input: "<a>one</a>",
+		},
+		{
+			name: "missing timestamp",
+			parser: &Parser{
+				Timestamp: "/a/@time",
+				Fields:    map[string]string{"value": "/a"},
+			},
+			input: "<a>1</a>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.parser.Compile())
+			_, err := tt.parser.Parse([]byte(tt.input))
+			require.Error(t, err)
+		})
+	}
+}
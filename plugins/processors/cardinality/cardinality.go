@@ -0,0 +1,182 @@
+package cardinality
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const sampleConfig = `
+  ## Maximum number of distinct values of each tag.
+  # limit = 1000
+
+  ## Tags to limit, globs are supported.
+  # tags = ["*"]
+
+  ## Count the values of the tags of each measurement separately, instead of
+  ## across all measurements.
+  # per_measurement = false
+
+  ## What to do with a metric with a new tag value once the limit is reached:
+  ##   "drop_tag"    -- remove the tag from the metric
+  ##   "replace"     -- replace the value of the tag with the sentinel
+  ##   "drop_metric" -- drop the metric
+  # action = "drop_tag"
+
+  ## Value of the tags over the limit with the "replace" action.
+  # sentinel = "other"
+`
+
+const (
+	actionDropTag    = "drop_tag"
+	actionReplace    = "replace"
+	actionDropMetric = "drop_metric"
+)
+
+type Cardinality struct {
+	Limit          int      `toml:"limit"`
+	Tags           []string `toml:"tags"`
+	PerMeasurement bool     `toml:"per_measurement"`
+	Action         string   `toml:"action"`
+	Sentinel       string   `toml:"sentinel"`
+
+	initialized bool
+	tagFilter   filter.Filter
+	keys        map[tagKey]map[string]struct{}
+	values      selfstat.Stat
+	limited     selfstat.Stat
+}
+
+// tagKey is a tag key, of a measurement when counting per measurement.
+type tagKey struct {
+	measurement string
+	key         string
+}
+
+func New() *Cardinality {
+	return &Cardinality{
+		Limit:    1000,
+		Tags:     []string{"*"},
+		Action:   actionDropTag,
+		Sentinel: "other",
+	}
+}
+
+func (c *Cardinality) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cardinality) Description() string {
+	return "Limit the number of distinct values of tags"
+}
+
+// SingleWorker implements processors.SingleWorker, the values of the tags
+// are counted over all the metrics.
+func (c *Cardinality) SingleWorker() {}
+
+func (c *Cardinality) Init() error {
+	c.initialized = true
+	return c.compile()
+}
+
+func (c *Cardinality) compile() error {
+	if c.Limit <= 0 {
+		return fmt.Errorf("limit must be greater than zero")
+	}
+	switch c.Action {
+	case actionDropTag, actionReplace, actionDropMetric:
+	default:
+		return fmt.Errorf("unknown action %q", c.Action)
+	}
+
+	var err error
+	c.tagFilter, err = filter.Compile(c.Tags)
+	if err != nil {
+		return err
+	}
+	c.keys = make(map[tagKey]map[string]struct{})
+
+	// The stats are the totals of all the cardinality processors, so that
+	// the number of internal series does not depend on the tags.
+	c.values = selfstat.Register("cardinality", "values", map[string]string{})
+	c.limited = selfstat.Register("cardinality", "limited", map[string]string{})
+	return nil
+}
+
+func (c *Cardinality) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	if !c.initialized {
+		if err := c.Init(); err != nil {
+			log.Printf("E! [processors.cardinality] Error initializing: %v", err)
+		}
+	}
+	if c.keys == nil {
+		return metrics
+	}
+
+	results := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		if !c.apply(m) {
+			m.Drop()
+			continue
+		}
+		results = append(results, m)
+	}
+	return results
+}
+
+// apply limits the values of the tags of the metric, it returns false if
+// the metric is to be dropped.
+func (c *Cardinality) apply(m telegraf.Metric) bool {
+	// The tags are copied as they may be removed while iterating.
+	tags := append([]*telegraf.Tag(nil), m.TagList()...)
+	for _, tag := range tags {
+		if c.tagFilter != nil && !c.tagFilter.Match(tag.Key) {
+			continue
+		}
+
+		values := c.tagValues(m.Name(), tag.Key)
+		if _, ok := values[tag.Value]; ok {
+			continue
+		}
+		if len(values) < c.Limit {
+			values[tag.Value] = struct{}{}
+			c.values.Incr(1)
+			continue
+		}
+
+		c.limited.Incr(1)
+		switch c.Action {
+		case actionDropTag:
+			m.RemoveTag(tag.Key)
+		case actionReplace:
+			m.AddTag(tag.Key, c.Sentinel)
+		case actionDropMetric:
+			return false
+		}
+	}
+	return true
+}
+
+// tagValues returns the values seen for the tag key.
+func (c *Cardinality) tagValues(measurement, key string) map[string]struct{} {
+	k := tagKey{key: key}
+	if c.PerMeasurement {
+		k.measurement = measurement
+	}
+	values, ok := c.keys[k]
+	if !ok {
+		values = make(map[string]struct{})
+		c.keys[k] = values
+	}
+	return values
+}
+
+func init() {
+	processors.Add("cardinality", func() telegraf.Processor {
+		return New()
+	})
+}
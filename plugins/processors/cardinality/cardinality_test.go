@@ -0,0 +1,167 @@
+package cardinality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func metricWithTags(name string, tags map[string]string) telegraf.Metric {
+	return testutil.MustMetric(name,
+		tags,
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+}
+
+func TestCardinality(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   func(c *Cardinality)
+		input    []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "drop tag",
+			input: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a", "host": "a"}),
+				metricWithTags("requests", map[string]string{"path": "/b", "host": "a"}),
+				metricWithTags("requests", map[string]string{"path": "/c", "host": "a"}),
+				metricWithTags("requests", map[string]string{"path": "/a", "host": "a"}),
+			},
+			expected: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a", "host": "a"}),
+				metricWithTags("requests", map[string]string{"path": "/b", "host": "a"}),
+				metricWithTags("requests", map[string]string{"host": "a"}),
+				metricWithTags("requests", map[string]string{"path": "/a", "host": "a"}),
+			},
+		},
+		{
+			name: "replace",
+			plugin: func(c *Cardinality) {
+				c.Action = "replace"
+			},
+			input: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a"}),
+				metricWithTags("requests", map[string]string{"path": "/b"}),
+				metricWithTags("requests", map[string]string{"path": "/c"}),
+				metricWithTags("requests", map[string]string{"path": "/d"}),
+			},
+			expected: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a"}),
+				metricWithTags("requests", map[string]string{"path": "/b"}),
+				metricWithTags("requests", map[string]string{"path": "other"}),
+				metricWithTags("requests", map[string]string{"path": "other"}),
+			},
+		},
+		{
+			name: "drop metric",
+			plugin: func(c *Cardinality) {
+				c.Action = "drop_metric"
+			},
+			input: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a"}),
+				metricWithTags("requests", map[string]string{"path": "/b"}),
+				metricWithTags("requests", map[string]string{"path": "/c"}),
+				metricWithTags("requests", map[string]string{"path": "/b"}),
+			},
+			expected: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a"}),
+				metricWithTags("requests", map[string]string{"path": "/b"}),
+				metricWithTags("requests", map[string]string{"path": "/b"}),
+			},
+		},
+		{
+			name: "tags filter",
+			plugin: func(c *Cardinality) {
+				c.Tags = []string{"path"}
+			},
+			input: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a", "host": "a"}),
+				metricWithTags("requests", map[string]string{"path": "/b", "host": "b"}),
+				metricWithTags("requests", map[string]string{"path": "/c", "host": "c"}),
+			},
+			expected: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a", "host": "a"}),
+				metricWithTags("requests", map[string]string{"path": "/b", "host": "b"}),
+				metricWithTags("requests", map[string]string{"host": "c"}),
+			},
+		},
+		{
+			name: "across measurements",
+			input: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a"}),
+				metricWithTags("errors", map[string]string{"path": "/b"}),
+				metricWithTags("latency", map[string]string{"path": "/c"}),
+			},
+			expected: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a"}),
+				metricWithTags("errors", map[string]string{"path": "/b"}),
+				metricWithTags("latency", map[string]string{}),
+			},
+		},
+		{
+			name: "per measurement",
+			plugin: func(c *Cardinality) {
+				c.PerMeasurement = true
+			},
+			input: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a"}),
+				metricWithTags("errors", map[string]string{"path": "/b"}),
+				metricWithTags("latency", map[string]string{"path": "/c"}),
+			},
+			expected: []telegraf.Metric{
+				metricWithTags("requests", map[string]string{"path": "/a"}),
+				metricWithTags("errors", map[string]string{"path": "/b"}),
+				metricWithTags("latency", map[string]string{"path": "/c"}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := New()
+			plugin.Limit = 2
+			if tt.plugin != nil {
+				tt.plugin(plugin)
+			}
+			actual := plugin.Apply(tt.input...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	values := selfstat.Register("cardinality", "values", map[string]string{})
+	limited := selfstat.Register("cardinality", "limited", map[string]string{})
+	valuesBefore, limitedBefore := values.Get(), limited.Get()
+
+	plugin := New()
+	plugin.Limit = 2
+	for _, path := range []string{"/a", "/b", "/b", "/c", "/d"} {
+		plugin.Apply(metricWithTags("requests", map[string]string{"path": path}))
+	}
+	require.Equal(t, valuesBefore+2, values.Get())
+	require.Equal(t, limitedBefore+2, limited.Get())
+
+	// the stats are the totals of all the processors
+	other := New()
+	for _, path := range []string{"/a", "/e"} {
+		other.Apply(metricWithTags("requests", map[string]string{"other_path": path}))
+	}
+	require.Equal(t, valuesBefore+4, values.Get())
+	require.Equal(t, limitedBefore+2, limited.Get())
+}
+
+func TestInvalidConfig(t *testing.T) {
+	plugin := New()
+	plugin.Action = "drop"
+	require.Error(t, plugin.Init())
+
+	plugin = New()
+	plugin.Limit = 0
+	require.Error(t, plugin.Init())
+}
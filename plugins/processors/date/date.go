@@ -0,0 +1,195 @@
+package date
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Format of the date, either a Go reference time layout, such as
+  ## "2006-01-02T15:04:05Z07:00" or "Jan", or one of "unix", "unix_ms",
+  ## "unix_us" or "unix_ns" for an epoch.
+  date_format = "Jan"
+
+  ## Timezone of the date, "Local" for the local timezone.  See
+  ## https://en.wikipedia.org/wiki/List_of_tz_database_time_zones for the
+  ## names.
+  # timezone = "UTC"
+
+  ## Add the metric time formatted with date_format as a tag or a field.
+  tag_key = "month"
+  # field_key = ""
+
+  ## Or set the metric time from a field or a tag parsed with date_format,
+  ## removing it from the metric if remove_source is true.
+  # source_field = ""
+  # source_tag = ""
+  # remove_source = false
+`
+
+type Date struct {
+	DateFormat   string `toml:"date_format"`
+	Timezone     string `toml:"timezone"`
+	TagKey       string `toml:"tag_key"`
+	FieldKey     string `toml:"field_key"`
+	SourceField  string `toml:"source_field"`
+	SourceTag    string `toml:"source_tag"`
+	RemoveSource bool   `toml:"remove_source"`
+
+	location *time.Location
+}
+
+func (d *Date) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Date) Description() string {
+	return "Add the metric time as a tag or field, or set it from a tag or field"
+}
+
+func (d *Date) compile() error {
+	if d.DateFormat == "" {
+		return errors.New("date_format must be set")
+	}
+
+	output := d.TagKey != "" || d.FieldKey != ""
+	source := d.SourceField != "" || d.SourceTag != ""
+	switch {
+	case !output && !source:
+		return errors.New("one of tag_key, field_key, source_field or source_tag must be set")
+	case output && source:
+		return errors.New("tag_key and field_key cannot be set with source_field or source_tag")
+	case d.SourceField != "" && d.SourceTag != "":
+		return errors.New("only one of source_field or source_tag can be set")
+	}
+
+	timezone := d.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %v", d.Timezone, err)
+	}
+	d.location = location
+	return nil
+}
+
+func (d *Date) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	if d.location == nil {
+		if err := d.compile(); err != nil {
+			log.Printf("E! [processors.date] Error initializing: %v", err)
+			return metrics
+		}
+	}
+
+	for _, m := range metrics {
+		if d.SourceField != "" || d.SourceTag != "" {
+			if err := d.setTime(m); err != nil {
+				log.Printf("D! [processors.date] Error setting the time of %q: %v", m.Name(), err)
+			}
+			continue
+		}
+
+		if d.TagKey != "" {
+			m.AddTag(d.TagKey, d.format(m.Time()))
+		}
+		if d.FieldKey != "" {
+			m.AddField(d.FieldKey, d.formatValue(m.Time()))
+		}
+	}
+	return metrics
+}
+
+// setTime sets the time of the metric from the source field or tag.
+func (d *Date) setTime(m telegraf.Metric) error {
+	var value interface{}
+	var ok bool
+	if d.SourceField != "" {
+		value, ok = m.GetField(d.SourceField)
+	} else {
+		value, ok = m.GetTag(d.SourceTag)
+	}
+	if !ok {
+		return nil
+	}
+
+	tm, err := d.parse(value)
+	if err != nil {
+		return err
+	}
+	m.SetTime(tm)
+
+	if d.RemoveSource {
+		if d.SourceField != "" {
+			m.RemoveField(d.SourceField)
+		} else {
+			m.RemoveTag(d.SourceTag)
+		}
+	}
+	return nil
+}
+
+// parse parses the value as a date in date_format.
+func (d *Date) parse(value interface{}) (time.Time, error) {
+	if isUnixFormat(d.DateFormat) {
+		if v, ok := value.(uint64); ok {
+			value = int64(v)
+		}
+		return internal.ParseTimestamp(value, d.DateFormat)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cannot parse %T with date_format %q", value, d.DateFormat)
+	}
+	return time.ParseInLocation(d.DateFormat, s, d.location)
+}
+
+// format formats the time as a string in date_format.
+func (d *Date) format(tm time.Time) string {
+	switch v := d.formatValue(tm).(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case string:
+		return v
+	}
+	return ""
+}
+
+// formatValue formats the time in date_format, epochs are integers.
+func (d *Date) formatValue(tm time.Time) interface{} {
+	switch strings.ToLower(d.DateFormat) {
+	case "unix":
+		return tm.Unix()
+	case "unix_ms":
+		return tm.UnixNano() / int64(time.Millisecond)
+	case "unix_us":
+		return tm.UnixNano() / int64(time.Microsecond)
+	case "unix_ns":
+		return tm.UnixNano()
+	}
+	return tm.In(d.location).Format(d.DateFormat)
+}
+
+func isUnixFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+		return true
+	}
+	return false
+}
+
+func init() {
+	processors.Add("date", func() telegraf.Processor {
+		return &Date{}
+	})
+}
@@ -0,0 +1,201 @@
+package date
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDate(t *testing.T) {
+	tm := time.Date(2019, time.June, 2, 22, 30, 15, 500000000, time.UTC)
+	tests := []struct {
+		name     string
+		plugin   *Date
+		input    telegraf.Metric
+		expected telegraf.Metric
+	}{
+		{
+			name: "month tag",
+			plugin: &Date{
+				DateFormat: "Jan",
+				TagKey:     "month",
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				tm,
+			),
+			expected: testutil.MustMetric("cpu",
+				map[string]string{"month": "Jun"},
+				map[string]interface{}{"value": 42},
+				tm,
+			),
+		},
+		{
+			name: "weekday tag in timezone",
+			plugin: &Date{
+				DateFormat: "Mon",
+				Timezone:   "Asia/Tokyo",
+				TagKey:     "weekday",
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				tm,
+			),
+			expected: testutil.MustMetric("cpu",
+				map[string]string{"weekday": "Mon"},
+				map[string]interface{}{"value": 42},
+				tm,
+			),
+		},
+		{
+			name: "unix_ms field and tag",
+			plugin: &Date{
+				DateFormat: "unix_ms",
+				TagKey:     "ms",
+				FieldKey:   "ms",
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				tm,
+			),
+			expected: testutil.MustMetric("cpu",
+				map[string]string{"ms": "1559514615500"},
+				map[string]interface{}{"value": 42, "ms": int64(1559514615500)},
+				tm,
+			),
+		},
+		{
+			name: "time from field",
+			plugin: &Date{
+				DateFormat:  "2006-01-02 15:04:05",
+				Timezone:    "America/New_York",
+				SourceField: "timestamp",
+			},
+			input: testutil.MustMetric("log",
+				map[string]string{},
+				map[string]interface{}{"timestamp": "2019-06-02 18:30:15"},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("log",
+				map[string]string{},
+				map[string]interface{}{"timestamp": "2019-06-02 18:30:15"},
+				time.Date(2019, time.June, 2, 22, 30, 15, 0, time.UTC),
+			),
+		},
+		{
+			name: "time from tag removed",
+			plugin: &Date{
+				DateFormat:   "unix",
+				SourceTag:    "ts",
+				RemoveSource: true,
+			},
+			input: testutil.MustMetric("log",
+				map[string]string{"ts": "1559514615.5"},
+				map[string]interface{}{"value": 42},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("log",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				tm,
+			),
+		},
+		{
+			name: "time from integer field",
+			plugin: &Date{
+				DateFormat:  "unix_ns",
+				SourceField: "ts",
+			},
+			input: testutil.MustMetric("log",
+				map[string]string{},
+				map[string]interface{}{"ts": uint64(1559514615500000000)},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("log",
+				map[string]string{},
+				map[string]interface{}{"ts": uint64(1559514615500000000)},
+				tm,
+			),
+		},
+		{
+			name: "invalid date is unchanged",
+			plugin: &Date{
+				DateFormat:   "2006-01-02",
+				SourceField:  "timestamp",
+				RemoveSource: true,
+			},
+			input: testutil.MustMetric("log",
+				map[string]string{},
+				map[string]interface{}{"timestamp": "yesterday"},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("log",
+				map[string]string{},
+				map[string]interface{}{"timestamp": "yesterday"},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "missing source",
+			plugin: &Date{
+				DateFormat:  "2006-01-02",
+				SourceField: "timestamp",
+			},
+			input: testutil.MustMetric("log",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("log",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				time.Unix(0, 0),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.plugin.Apply(tt.input)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual)
+		})
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *Date
+	}{
+		{
+			name:   "no date_format",
+			plugin: &Date{TagKey: "month"},
+		},
+		{
+			name:   "no output or source",
+			plugin: &Date{DateFormat: "Jan"},
+		},
+		{
+			name:   "output and source",
+			plugin: &Date{DateFormat: "Jan", TagKey: "month", SourceField: "ts"},
+		},
+		{
+			name:   "source field and tag",
+			plugin: &Date{DateFormat: "unix", SourceField: "ts", SourceTag: "ts"},
+		},
+		{
+			name:   "timezone",
+			plugin: &Date{DateFormat: "Jan", TagKey: "month", Timezone: "Mars/Olympus"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.plugin.compile())
+		})
+	}
+}
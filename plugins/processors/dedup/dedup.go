@@ -0,0 +1,113 @@
+package dedup
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Maximum time to suppress output, once it has elapsed an unchanged metric
+  ## is emitted again as a heartbeat.
+  # dedup_interval = "600s"
+`
+
+type Dedup struct {
+	DedupInterval internal.Duration `toml:"dedup_interval"`
+
+	cache     map[uint64]*entry
+	lastClean time.Time
+	now       func() time.Time
+}
+
+// entry is the last metric emitted for a series.
+type entry struct {
+	fields map[string]interface{}
+	time   time.Time
+}
+
+func New() *Dedup {
+	return &Dedup{
+		DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+		cache:         make(map[uint64]*entry),
+		now:           time.Now,
+	}
+}
+
+func (d *Dedup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Dedup) Description() string {
+	return "Filter metrics with repeating field values"
+}
+
+func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	d.clean()
+
+	results := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		id := m.HashID()
+		last, ok := d.cache[id]
+		if ok {
+			// Metrics older than the last one emitted are passed
+			// through without being compared.
+			if m.Time().Before(last.time) {
+				results = append(results, m)
+				continue
+			}
+			if d.duplicate(last, m) {
+				m.Drop()
+				continue
+			}
+		}
+
+		// The fields are copied, the metric may be modified by the
+		// processors after this one.
+		d.cache[id] = &entry{fields: m.Fields(), time: m.Time()}
+		results = append(results, m)
+	}
+	return results
+}
+
+// duplicate returns true if the metric has the same fields as the last one
+// emitted for its series and the dedup interval has not elapsed.
+func (d *Dedup) duplicate(last *entry, m telegraf.Metric) bool {
+	if m.Time().Sub(last.time) >= d.DedupInterval.Duration {
+		return false
+	}
+	if len(m.FieldList()) != len(last.fields) {
+		return false
+	}
+	for _, field := range m.FieldList() {
+		value, ok := last.fields[field.Key]
+		if !ok || value != field.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// clean removes the series that have not been emitted within the dedup
+// interval, it runs at most once per interval.
+func (d *Dedup) clean() {
+	now := d.now()
+	if now.Sub(d.lastClean) < d.DedupInterval.Duration {
+		return
+	}
+	d.lastClean = now
+
+	for id, last := range d.cache {
+		if now.Sub(last.time) >= d.DedupInterval.Duration {
+			delete(d.cache, id)
+		}
+	}
+}
+
+func init() {
+	processors.Add("dedup", func() telegraf.Processor {
+		return New()
+	})
+}
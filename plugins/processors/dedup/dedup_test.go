@@ -0,0 +1,168 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func value(v interface{}, tm time.Time) telegraf.Metric {
+	return testutil.MustMetric("snmp",
+		map[string]string{"agent_host": "router"},
+		map[string]interface{}{"value": v},
+		tm,
+	)
+}
+
+func TestDedup(t *testing.T) {
+	start := time.Unix(1560000000, 0)
+	tests := []struct {
+		name     string
+		input    []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "unchanged value is dropped",
+			input: []telegraf.Metric{
+				value(1, start),
+				value(1, start.Add(time.Minute)),
+			},
+			expected: []telegraf.Metric{
+				value(1, start),
+			},
+		},
+		{
+			name: "changed value is emitted",
+			input: []telegraf.Metric{
+				value(1, start),
+				value(2, start.Add(time.Minute)),
+				value(2, start.Add(2*time.Minute)),
+			},
+			expected: []telegraf.Metric{
+				value(1, start),
+				value(2, start.Add(time.Minute)),
+			},
+		},
+		{
+			name: "type change is emitted",
+			input: []telegraf.Metric{
+				value(1, start),
+				value(1.0, start.Add(time.Minute)),
+			},
+			expected: []telegraf.Metric{
+				value(1, start),
+				value(1.0, start.Add(time.Minute)),
+			},
+		},
+		{
+			name: "heartbeat after dedup interval",
+			input: []telegraf.Metric{
+				value(1, start),
+				value(1, start.Add(5*time.Minute)),
+				value(1, start.Add(10*time.Minute)),
+				value(1, start.Add(15*time.Minute)),
+			},
+			expected: []telegraf.Metric{
+				value(1, start),
+				value(1, start.Add(10*time.Minute)),
+			},
+		},
+		{
+			name: "added field is emitted",
+			input: []telegraf.Metric{
+				value(1, start),
+				testutil.MustMetric("snmp",
+					map[string]string{"agent_host": "router"},
+					map[string]interface{}{"value": 1, "other": 2},
+					start.Add(time.Minute),
+				),
+			},
+			expected: []telegraf.Metric{
+				value(1, start),
+				testutil.MustMetric("snmp",
+					map[string]string{"agent_host": "router"},
+					map[string]interface{}{"value": 1, "other": 2},
+					start.Add(time.Minute),
+				),
+			},
+		},
+		{
+			name: "series are keyed on name and tags",
+			input: []telegraf.Metric{
+				value(1, start),
+				testutil.MustMetric("snmp",
+					map[string]string{"agent_host": "switch"},
+					map[string]interface{}{"value": 1},
+					start.Add(time.Minute),
+				),
+				testutil.MustMetric("sysctl",
+					map[string]string{"agent_host": "router"},
+					map[string]interface{}{"value": 1},
+					start.Add(time.Minute),
+				),
+			},
+			expected: []telegraf.Metric{
+				value(1, start),
+				testutil.MustMetric("snmp",
+					map[string]string{"agent_host": "switch"},
+					map[string]interface{}{"value": 1},
+					start.Add(time.Minute),
+				),
+				testutil.MustMetric("sysctl",
+					map[string]string{"agent_host": "router"},
+					map[string]interface{}{"value": 1},
+					start.Add(time.Minute),
+				),
+			},
+		},
+		{
+			name: "older metric is passed through",
+			input: []telegraf.Metric{
+				value(1, start),
+				value(1, start.Add(-time.Minute)),
+				value(1, start.Add(time.Minute)),
+			},
+			expected: []telegraf.Metric{
+				value(1, start),
+				value(1, start.Add(-time.Minute)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := New()
+			plugin.now = func() time.Time { return start }
+			actual := plugin.Apply(tt.input...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestModifiedAfterApply(t *testing.T) {
+	start := time.Unix(1560000000, 0)
+	plugin := New()
+	plugin.now = func() time.Time { return start }
+
+	m := value(1, start)
+	plugin.Apply(m)
+	m.AddField("value", 2)
+
+	actual := plugin.Apply(value(1, start.Add(time.Minute)))
+	require.Len(t, actual, 0)
+}
+
+func TestClean(t *testing.T) {
+	now := time.Unix(1560000000, 0)
+	plugin := New()
+	plugin.now = func() time.Time { return now }
+
+	plugin.Apply(value(1, now))
+	require.Len(t, plugin.cache, 1)
+
+	now = now.Add(time.Hour)
+	plugin.Apply()
+	require.Len(t, plugin.cache, 0)
+}
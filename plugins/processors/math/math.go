@@ -0,0 +1,106 @@
+package math
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/expr"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Fields to add to the metrics, the key is the name of the field and the
+  ## value an expression computing it.  The expressions refer to the metric
+  ## using name, fields.<key> and tags.<key>.
+  [processors.math.fields]
+    # used_percent = "fields.used / fields.total * 100"
+`
+
+type Math struct {
+	Fields map[string]string `toml:"fields"`
+
+	expressions []expression
+	initialized bool
+}
+
+type expression struct {
+	key  string
+	expr *expr.Expression
+}
+
+func (p *Math) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Math) Description() string {
+	return "Add fields computed from the fields and tags of the metrics"
+}
+
+func (p *Math) Init() error {
+	p.initialized = true
+	return p.compile()
+}
+
+func (p *Math) compile() error {
+	if len(p.Fields) == 0 {
+		return fmt.Errorf("no fields to compute")
+	}
+
+	keys := make([]string, 0, len(p.Fields))
+	for key := range p.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	expressions := make([]expression, 0, len(keys))
+	for _, key := range keys {
+		e, err := expr.Compile(p.Fields[key])
+		if err != nil {
+			return fmt.Errorf("field %q: %v", key, err)
+		}
+		expressions = append(expressions, expression{key: key, expr: e})
+	}
+	p.expressions = expressions
+	return nil
+}
+
+func (p *Math) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	if !p.initialized {
+		if err := p.Init(); err != nil {
+			log.Printf("E! [processors.math] Error initializing: %v", err)
+		}
+	}
+	if p.expressions == nil {
+		return metrics
+	}
+
+	for _, m := range metrics {
+		// All the expressions are evaluated before adding the fields, so
+		// that they do not depend on each other.
+		values := make([]interface{}, len(p.expressions))
+		for i, e := range p.expressions {
+			v, err := e.expr.Value(m)
+			if err != nil {
+				log.Printf("E! [processors.math] Error computing field %q of %q: %v",
+					e.key, m.Name(), err)
+				continue
+			}
+			values[i] = v
+		}
+
+		for i, e := range p.expressions {
+			if values[i] != nil {
+				m.AddField(e.key, values[i])
+			}
+		}
+	}
+	return metrics
+}
+
+func init() {
+	processors.Add("math", func() telegraf.Processor {
+		return &Math{}
+	})
+}
@@ -0,0 +1,138 @@
+package math
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMath(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]string
+		input    telegraf.Metric
+		expected telegraf.Metric
+	}{
+		{
+			name: "percent",
+			fields: map[string]string{
+				"used_percent": "fields.used / fields.total * 100",
+			},
+			input: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(25), "total": int64(200)},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{
+					"used":         int64(25),
+					"total":        int64(200),
+					"used_percent": 12.5,
+				},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "integer types",
+			fields: map[string]string{
+				"free":  "fields.total - fields.used",
+				"total": "fields.total * 2",
+			},
+			input: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": uint64(25), "total": int64(200)},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{
+					"used":  uint64(25),
+					"total": int64(400),
+					"free":  int64(175),
+				},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "tags",
+			fields: map[string]string{
+				"usage_per_core": "fields.usage / tags.cores",
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{"cores": "4"},
+				map[string]interface{}{"usage": 200.0},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("cpu",
+				map[string]string{"cores": "4"},
+				map[string]interface{}{"usage": 200.0, "usage_per_core": 50.0},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "missing operand",
+			fields: map[string]string{
+				"used_percent": "fields.used / fields.total * 100",
+				"double":       "fields.used * 2",
+			},
+			input: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(25)},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(25), "double": int64(50)},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "division by zero",
+			fields: map[string]string{
+				"used_percent": "fields.used / fields.total * 100",
+			},
+			input: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(25), "total": int64(0)},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(25), "total": int64(0)},
+				time.Unix(0, 0),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Math{Fields: tt.fields}
+			actual := plugin.Apply(tt.input)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual)
+		})
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	plugin := &Math{}
+	require.Error(t, plugin.compile())
+
+	plugin = &Math{Fields: map[string]string{"x": "fields.a +"}}
+	require.Error(t, plugin.compile())
+}
+
+func TestApplyCompileError(t *testing.T) {
+	plugin := &Math{Fields: map[string]string{"x": "fields.a +"}}
+	m := testutil.MustMetric("cpu", map[string]string{},
+		map[string]interface{}{"a": 1}, time.Unix(0, 0))
+
+	// the error is logged once, the metrics are passed unchanged
+	for i := 0; i < 2; i++ {
+		actual := plugin.Apply(m.Copy())
+		testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, actual)
+		require.True(t, plugin.initialized)
+	}
+}
@@ -0,0 +1,126 @@
+package pivot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPivot(t *testing.T) {
+	tests := []struct {
+		name     string
+		pivot    *Pivot
+		metrics  []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "simple",
+			pivot: &Pivot{
+				TagKey:   "name",
+				ValueKey: "value",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"name": "idle_time"},
+					map[string]interface{}{"value": int64(42)},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"idle_time": int64(42)},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "missing tag",
+			pivot: &Pivot{
+				TagKey:   "name",
+				ValueKey: "value",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"foo": "idle_time"},
+					map[string]interface{}{"value": int64(42)},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"foo": "idle_time"},
+					map[string]interface{}{"value": int64(42)},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "missing field",
+			pivot: &Pivot{
+				TagKey:   "name",
+				ValueKey: "value",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"name": "idle_time"},
+					map[string]interface{}{"foo": int64(42)},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"name": "idle_time"},
+					map[string]interface{}{"foo": int64(42)},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "other fields are kept",
+			pivot: &Pivot{
+				TagKey:   "name",
+				ValueKey: "value",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"name": "idle_time", "host": "a"},
+					map[string]interface{}{"value": int64(42), "count": int64(1)},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"idle_time": int64(42), "count": int64(1)},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.pivot.Apply(tt.metrics...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestPivotFieldMeta(t *testing.T) {
+	m := testutil.MustMetric("sensors",
+		map[string]string{"name": "cpu_temp"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0),
+	)
+	m.SetFieldMeta("value", telegraf.FieldMeta{Unit: "celsius"})
+
+	pivot := &Pivot{TagKey: "name", ValueKey: "value"}
+	actual := pivot.Apply(m)
+
+	meta, ok := actual[0].GetFieldMeta("cpu_temp")
+	require.True(t, ok)
+	require.Equal(t, "celsius", meta.Unit)
+}
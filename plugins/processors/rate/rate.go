@@ -0,0 +1,300 @@
+package rate
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Fields of counters to compute the rate of, globs are supported.  Fields
+  ## that are not numbers are ignored.
+  # fields = ["*"]
+
+  ## Suffix of the field with the per second rate of change.
+  # rate_suffix = "_rate"
+
+  ## Suffix of the field with the difference between the values, if set.
+  # delta_suffix = ""
+
+  ## Size in bits of the counters, 32 or 64.  When set, a counter decreasing
+  ## from the upper half of its range is assumed to have wrapped around.  Any
+  ## other decrease is a counter reset and no rate is computed.
+  # counter_size = 0
+
+  ## Maximum time between two values of a counter, when the gap is larger no
+  ## rate is computed.  Zero for no maximum.
+  # max_gap = "0s"
+
+  ## Series that are not seen for this long are forgotten.
+  # expiry = "1h"
+
+  ## Remove the counter fields, keeping only the computed fields.
+  # drop_original = false
+`
+
+type Rate struct {
+	Fields       []string          `toml:"fields"`
+	RateSuffix   string            `toml:"rate_suffix"`
+	DeltaSuffix  string            `toml:"delta_suffix"`
+	CounterSize  int               `toml:"counter_size"`
+	MaxGap       internal.Duration `toml:"max_gap"`
+	Expiry       internal.Duration `toml:"expiry"`
+	DropOriginal bool              `toml:"drop_original"`
+
+	fieldFilter filter.Filter
+	series      map[uint64]*series
+	lastExpire  time.Time
+	now         func() time.Time
+}
+
+// series is the previous value of the counters of a series.
+type series struct {
+	fields   map[string]sample
+	lastSeen time.Time
+}
+
+type sample struct {
+	value interface{}
+	time  time.Time
+}
+
+func New() *Rate {
+	return &Rate{
+		Fields:     []string{"*"},
+		RateSuffix: "_rate",
+		Expiry:     internal.Duration{Duration: time.Hour},
+		now:        time.Now,
+	}
+}
+
+func (r *Rate) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Rate) Description() string {
+	return "Compute the rate of change of counters"
+}
+
+func (r *Rate) compile() error {
+	switch r.CounterSize {
+	case 0, 32, 64:
+	default:
+		return fmt.Errorf("invalid counter_size %d, must be 32 or 64", r.CounterSize)
+	}
+
+	var err error
+	r.fieldFilter, err = filter.Compile(r.Fields)
+	if err != nil {
+		return err
+	}
+	r.series = make(map[uint64]*series)
+	return nil
+}
+
+func (r *Rate) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	if r.series == nil {
+		if err := r.compile(); err != nil {
+			log.Printf("E! [processors.rate] Error initializing: %v", err)
+			return metrics
+		}
+	}
+
+	now := r.now()
+	r.expire(now)
+
+	results := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		r.apply(m, now)
+		if len(m.FieldList()) == 0 {
+			m.Drop()
+			continue
+		}
+		results = append(results, m)
+	}
+	return results
+}
+
+// apply adds the computed fields of the counters of the metric.
+func (r *Rate) apply(m telegraf.Metric, now time.Time) {
+	id := m.HashID()
+	s, ok := r.series[id]
+	if !ok || r.expired(s, now) {
+		s = &series{fields: make(map[string]sample)}
+		r.series[id] = s
+	}
+	s.lastSeen = now
+
+	var counters []string
+	computed := make(map[string]interface{})
+	for _, field := range m.FieldList() {
+		if r.fieldFilter != nil && !r.fieldFilter.Match(field.Key) {
+			continue
+		}
+		if !isNumber(field.Value) {
+			continue
+		}
+		counters = append(counters, field.Key)
+
+		cur := sample{value: field.Value, time: m.Time()}
+		prev, ok := s.fields[field.Key]
+		if !ok {
+			s.fields[field.Key] = cur
+			continue
+		}
+
+		// Values out of order or with the same time are skipped.
+		dt := cur.time.Sub(prev.time)
+		if dt <= 0 {
+			continue
+		}
+		s.fields[field.Key] = cur
+
+		if r.MaxGap.Duration > 0 && dt > r.MaxGap.Duration {
+			continue
+		}
+
+		delta, ok := r.delta(prev.value, cur.value)
+		if !ok {
+			continue
+		}
+		computed[field.Key+r.RateSuffix] = toFloat(delta) / dt.Seconds()
+		if r.DeltaSuffix != "" {
+			computed[field.Key+r.DeltaSuffix] = delta
+		}
+	}
+
+	if r.DropOriginal {
+		for _, key := range counters {
+			m.RemoveField(key)
+		}
+	}
+	for key, value := range computed {
+		m.AddField(key, value)
+	}
+}
+
+// delta returns the increase of the counter from prev to cur, with the type
+// of cur.  It returns false when the counter was reset.
+func (r *Rate) delta(prev, cur interface{}) (interface{}, bool) {
+	p, pok := toUint(prev)
+	c, cok := toUint(cur)
+	if pok && cok {
+		if c < p {
+			if !r.wrappedUint(p) {
+				return nil, false
+			}
+			// Unsigned subtraction wraps around 2^64, for smaller
+			// counters the result is brought back into their range.
+			if r.CounterSize < 64 {
+				c += 1 << uint(r.CounterSize)
+			}
+		}
+		d := c - p
+		if _, ok := cur.(int64); ok {
+			if d > math.MaxInt64 {
+				return nil, false
+			}
+			return int64(d), true
+		}
+		return d, true
+	}
+
+	pf, cf := toFloat(prev), toFloat(cur)
+	if math.IsNaN(pf) || math.IsNaN(cf) || pf < 0 || cf < 0 {
+		return nil, false
+	}
+	if cf < pf {
+		if !r.wrappedFloat(pf) {
+			return nil, false
+		}
+		cf += math.Pow(2, float64(r.CounterSize))
+	}
+	return cf - pf, true
+}
+
+// wrappedUint returns true if a counter decreasing from prev has wrapped
+// around, that is if prev is in the upper half of the range of the counter.
+func (r *Rate) wrappedUint(prev uint64) bool {
+	if r.CounterSize == 0 {
+		return false
+	}
+	size := uint(r.CounterSize)
+	if prev < 1<<(size-1) {
+		return false
+	}
+	return size == 64 || prev < 1<<size
+}
+
+// wrappedFloat is wrappedUint for counters with float values.
+func (r *Rate) wrappedFloat(prev float64) bool {
+	if r.CounterSize == 0 {
+		return false
+	}
+	size := float64(r.CounterSize)
+	return prev >= math.Pow(2, size-1) && prev <= math.Pow(2, size)
+}
+
+func (r *Rate) expired(s *series, now time.Time) bool {
+	return r.Expiry.Duration > 0 && now.Sub(s.lastSeen) > r.Expiry.Duration
+}
+
+// expire removes the series that have not been seen for longer than the
+// expiry, it runs at most once per expiry.
+func (r *Rate) expire(now time.Time) {
+	if r.Expiry.Duration <= 0 || now.Sub(r.lastExpire) < r.Expiry.Duration {
+		return
+	}
+	r.lastExpire = now
+
+	for id, s := range r.series {
+		if r.expired(s, now) {
+			delete(r.series, id)
+		}
+	}
+}
+
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case int64, uint64, float64:
+		return true
+	}
+	return false
+}
+
+// toUint returns the value as an uint64 if it is a non negative integer.
+func toUint(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case int64:
+		if v >= 0 {
+			return uint64(v), true
+		}
+	case uint64:
+		return v, true
+	}
+	return 0, false
+}
+
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return math.NaN()
+}
+
+func init() {
+	processors.Add("rate", func() telegraf.Processor {
+		return New()
+	})
+}
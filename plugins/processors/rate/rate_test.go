@@ -0,0 +1,300 @@
+package rate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func counter(value interface{}, sec int64) telegraf.Metric {
+	return testutil.MustMetric("net",
+		map[string]string{"interface": "eth0"},
+		map[string]interface{}{"bytes_recv": value},
+		time.Unix(sec, 0),
+	)
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   func(r *Rate)
+		input    []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "rate",
+			input: []telegraf.Metric{
+				counter(int64(100), 0),
+				counter(int64(300), 10),
+			},
+			expected: []telegraf.Metric{
+				counter(int64(100), 0),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{
+						"bytes_recv":      int64(300),
+						"bytes_recv_rate": 20.0,
+					},
+					time.Unix(10, 0),
+				),
+			},
+		},
+		{
+			name: "delta and drop original",
+			plugin: func(r *Rate) {
+				r.DeltaSuffix = "_delta"
+				r.DropOriginal = true
+			},
+			input: []telegraf.Metric{
+				counter(uint64(100), 0),
+				counter(uint64(300), 10),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{
+						"bytes_recv_rate":  20.0,
+						"bytes_recv_delta": uint64(200),
+					},
+					time.Unix(10, 0),
+				),
+			},
+		},
+		{
+			name: "counter reset",
+			input: []telegraf.Metric{
+				counter(int64(300), 0),
+				counter(int64(100), 10),
+				counter(int64(200), 20),
+			},
+			expected: []telegraf.Metric{
+				counter(int64(300), 0),
+				counter(int64(100), 10),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{
+						"bytes_recv":      int64(200),
+						"bytes_recv_rate": 10.0,
+					},
+					time.Unix(20, 0),
+				),
+			},
+		},
+		{
+			name: "32 bit wraparound",
+			plugin: func(r *Rate) {
+				r.CounterSize = 32
+				r.DeltaSuffix = "_delta"
+			},
+			input: []telegraf.Metric{
+				counter(uint64(math.MaxUint32-99), 0),
+				counter(uint64(100), 10),
+			},
+			expected: []telegraf.Metric{
+				counter(uint64(math.MaxUint32-99), 0),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{
+						"bytes_recv":       uint64(100),
+						"bytes_recv_rate":  20.0,
+						"bytes_recv_delta": uint64(200),
+					},
+					time.Unix(10, 0),
+				),
+			},
+		},
+		{
+			name: "64 bit wraparound",
+			plugin: func(r *Rate) {
+				r.CounterSize = 64
+				r.DeltaSuffix = "_delta"
+			},
+			input: []telegraf.Metric{
+				counter(uint64(math.MaxUint64-99), 0),
+				counter(uint64(100), 10),
+			},
+			expected: []telegraf.Metric{
+				counter(uint64(math.MaxUint64-99), 0),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{
+						"bytes_recv":       uint64(100),
+						"bytes_recv_rate":  20.0,
+						"bytes_recv_delta": uint64(200),
+					},
+					time.Unix(10, 0),
+				),
+			},
+		},
+		{
+			name: "decrease from lower half is a reset",
+			plugin: func(r *Rate) {
+				r.CounterSize = 32
+			},
+			input: []telegraf.Metric{
+				counter(uint64(1000), 0),
+				counter(uint64(100), 10),
+			},
+			expected: []telegraf.Metric{
+				counter(uint64(1000), 0),
+				counter(uint64(100), 10),
+			},
+		},
+		{
+			name: "float wraparound",
+			plugin: func(r *Rate) {
+				r.CounterSize = 32
+			},
+			input: []telegraf.Metric{
+				counter(float64(math.MaxUint32-99), 0),
+				counter(float64(100), 10),
+			},
+			expected: []telegraf.Metric{
+				counter(float64(math.MaxUint32-99), 0),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{
+						"bytes_recv":      float64(100),
+						"bytes_recv_rate": 20.0,
+					},
+					time.Unix(10, 0),
+				),
+			},
+		},
+		{
+			name: "max gap",
+			plugin: func(r *Rate) {
+				r.MaxGap = internal.Duration{Duration: 30 * time.Second}
+			},
+			input: []telegraf.Metric{
+				counter(int64(100), 0),
+				counter(int64(200), 60),
+				counter(int64(500), 90),
+			},
+			expected: []telegraf.Metric{
+				counter(int64(100), 0),
+				counter(int64(200), 60),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{
+						"bytes_recv":      int64(500),
+						"bytes_recv_rate": 10.0,
+					},
+					time.Unix(90, 0),
+				),
+			},
+		},
+		{
+			name: "out of order",
+			input: []telegraf.Metric{
+				counter(int64(100), 10),
+				counter(int64(50), 0),
+				counter(int64(300), 20),
+			},
+			expected: []telegraf.Metric{
+				counter(int64(100), 10),
+				counter(int64(50), 0),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{
+						"bytes_recv":      int64(300),
+						"bytes_recv_rate": 20.0,
+					},
+					time.Unix(20, 0),
+				),
+			},
+		},
+		{
+			name: "series and fields",
+			plugin: func(r *Rate) {
+				r.Fields = []string{"bytes_*"}
+			},
+			input: []telegraf.Metric{
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{"bytes_recv": 100, "packets_recv": 1, "name": "x"},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth1"},
+					map[string]interface{}{"bytes_recv": 1000},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{"bytes_recv": 200, "packets_recv": 2, "name": "x"},
+					time.Unix(10, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{"bytes_recv": 100, "packets_recv": 1, "name": "x"},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth1"},
+					map[string]interface{}{"bytes_recv": 1000},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("net",
+					map[string]string{"interface": "eth0"},
+					map[string]interface{}{
+						"bytes_recv":      200,
+						"packets_recv":    2,
+						"name":            "x",
+						"bytes_recv_rate": 10.0,
+					},
+					time.Unix(10, 0),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := New()
+			if tt.plugin != nil {
+				tt.plugin(plugin)
+			}
+			var actual []telegraf.Metric
+			for _, m := range tt.input {
+				actual = append(actual, plugin.Apply(m)...)
+			}
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	plugin := New()
+	plugin.now = func() time.Time { return now }
+
+	plugin.Apply(counter(int64(100), 0))
+	require.Len(t, plugin.series, 1)
+
+	now = now.Add(2 * time.Hour)
+	actual := plugin.Apply(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 1},
+		time.Unix(0, 0),
+	))
+	require.Len(t, actual, 1)
+	require.Len(t, plugin.series, 1)
+
+	// the expired series starts over
+	now = now.Add(2 * time.Hour)
+	actual = plugin.Apply(counter(int64(200), 10))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{counter(int64(200), 10)}, actual)
+}
+
+func TestInvalidCounterSize(t *testing.T) {
+	plugin := New()
+	plugin.CounterSize = 16
+	require.Error(t, plugin.compile())
+}
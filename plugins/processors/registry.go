@@ -9,3 +9,16 @@ var Processors = map[string]Creator{}
 func Add(name string, creator Creator) {
 	Processors[name] = creator
 }
+
+// SingleWorker is implemented by processors whose state covers all the
+// metrics, they can not be run on more than one worker.
+type SingleWorker interface {
+	SingleWorker()
+}
+
+// Initializer is implemented by processors that check their settings, Init is
+// called when the configuration is loaded so that bad settings are reported
+// at startup.
+type Initializer interface {
+	Init() error
+}
@@ -0,0 +1,36 @@
+package starlark
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf/metric"
+	"go.starlark.net/starlark"
+)
+
+// newMetric is the Metric builtin, it creates a new metric with the name and
+// the current time.
+func newMetric(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name starlark.String
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &name); err != nil {
+		return nil, err
+	}
+
+	m, err := metric.New(name.GoString(), nil, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &Metric{metric: m}, nil
+}
+
+// deepcopy is the deepcopy builtin, it returns a copy of the metric that can
+// be modified and returned independently of the original.
+func deepcopy(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var sm *Metric
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &sm); err != nil {
+		return nil, err
+	}
+
+	// The copy is not tracked, its delivery is not reported to the input.
+	dup := metric.FromMetric(sm.metric)
+	return &Metric{metric: dup}, nil
+}
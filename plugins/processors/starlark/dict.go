@@ -0,0 +1,479 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.starlark.net/starlark"
+)
+
+// metricDict is implemented by the tags and fields of a metric, they support
+// the methods of a Starlark dict.
+type metricDict interface {
+	starlark.IterableMapping
+	starlark.HasSetKey
+	Len() int
+	Keys() []starlark.Value
+	Delete(k starlark.Value) (v starlark.Value, found bool, err error)
+	Clear() error
+}
+
+// TagDict is the Starlark value of the tags of a metric.
+type TagDict struct {
+	m *Metric
+}
+
+func (d *TagDict) String() string {
+	return dictString(d)
+}
+
+func (d *TagDict) Type() string {
+	return "Tags"
+}
+
+func (d *TagDict) Freeze() {
+}
+
+func (d *TagDict) Truth() starlark.Bool {
+	return len(d.m.metric.TagList()) != 0
+}
+
+func (d *TagDict) Hash() (uint32, error) {
+	return 0, errors.New("unhashable type: Tags")
+}
+
+func (d *TagDict) AttrNames() []string {
+	return dictMethodNames
+}
+
+func (d *TagDict) Attr(name string) (starlark.Value, error) {
+	return dictAttr(d, name)
+}
+
+func (d *TagDict) Len() int {
+	return len(d.m.metric.TagList())
+}
+
+func (d *TagDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	k, ok := key.(starlark.String)
+	if !ok {
+		return nil, false, fmt.Errorf("type error: tag key must be a string, got %s", key.Type())
+	}
+	v, ok := d.m.metric.GetTag(k.GoString())
+	if !ok {
+		return starlark.None, false, nil
+	}
+	return starlark.String(v), true, nil
+}
+
+func (d *TagDict) SetKey(key, value starlark.Value) error {
+	if d.m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	k, ok := key.(starlark.String)
+	if !ok {
+		return fmt.Errorf("type error: tag key must be a string, got %s", key.Type())
+	}
+	v, ok := value.(starlark.String)
+	if !ok {
+		return fmt.Errorf("type error: tag value must be a string, got %s", value.Type())
+	}
+	d.m.metric.AddTag(k.GoString(), v.GoString())
+	return nil
+}
+
+func (d *TagDict) Items() []starlark.Tuple {
+	items := make([]starlark.Tuple, 0, len(d.m.metric.TagList()))
+	for _, tag := range d.m.metric.TagList() {
+		items = append(items, starlark.Tuple{
+			starlark.String(tag.Key), starlark.String(tag.Value),
+		})
+	}
+	return items
+}
+
+func (d *TagDict) Keys() []starlark.Value {
+	keys := make([]starlark.Value, 0, len(d.m.metric.TagList()))
+	for _, tag := range d.m.metric.TagList() {
+		keys = append(keys, starlark.String(tag.Key))
+	}
+	return keys
+}
+
+func (d *TagDict) Iterate() starlark.Iterator {
+	return &keyIterator{keys: d.Keys()}
+}
+
+func (d *TagDict) Delete(key starlark.Value) (starlark.Value, bool, error) {
+	if d.m.frozen {
+		return nil, false, errors.New("cannot modify frozen metric")
+	}
+	v, found, err := d.Get(key)
+	if err != nil || !found {
+		return v, found, err
+	}
+	d.m.metric.RemoveTag(string(key.(starlark.String)))
+	return v, true, nil
+}
+
+func (d *TagDict) Clear() error {
+	if d.m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	for _, key := range d.Keys() {
+		d.m.metric.RemoveTag(string(key.(starlark.String)))
+	}
+	return nil
+}
+
+// FieldDict is the Starlark value of the fields of a metric.
+type FieldDict struct {
+	m *Metric
+}
+
+func (d *FieldDict) String() string {
+	return dictString(d)
+}
+
+func (d *FieldDict) Type() string {
+	return "Fields"
+}
+
+func (d *FieldDict) Freeze() {
+}
+
+func (d *FieldDict) Truth() starlark.Bool {
+	return len(d.m.metric.FieldList()) != 0
+}
+
+func (d *FieldDict) Hash() (uint32, error) {
+	return 0, errors.New("unhashable type: Fields")
+}
+
+func (d *FieldDict) AttrNames() []string {
+	return dictMethodNames
+}
+
+func (d *FieldDict) Attr(name string) (starlark.Value, error) {
+	return dictAttr(d, name)
+}
+
+func (d *FieldDict) Len() int {
+	return len(d.m.metric.FieldList())
+}
+
+func (d *FieldDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	k, ok := key.(starlark.String)
+	if !ok {
+		return nil, false, fmt.Errorf("type error: field key must be a string, got %s", key.Type())
+	}
+	v, ok := d.m.metric.GetField(k.GoString())
+	if !ok {
+		return starlark.None, false, nil
+	}
+	sv, err := asStarlarkValue(v)
+	return sv, err == nil, err
+}
+
+func (d *FieldDict) SetKey(key, value starlark.Value) error {
+	if d.m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	k, ok := key.(starlark.String)
+	if !ok {
+		return fmt.Errorf("type error: field key must be a string, got %s", key.Type())
+	}
+	v, err := asGoValue(value)
+	if err != nil {
+		return err
+	}
+	d.m.metric.AddField(k.GoString(), v)
+	return nil
+}
+
+func (d *FieldDict) Items() []starlark.Tuple {
+	items := make([]starlark.Tuple, 0, len(d.m.metric.FieldList()))
+	for _, field := range d.m.metric.FieldList() {
+		v, err := asStarlarkValue(field.Value)
+		if err != nil {
+			continue
+		}
+		items = append(items, starlark.Tuple{starlark.String(field.Key), v})
+	}
+	return items
+}
+
+func (d *FieldDict) Keys() []starlark.Value {
+	keys := make([]starlark.Value, 0, len(d.m.metric.FieldList()))
+	for _, field := range d.m.metric.FieldList() {
+		keys = append(keys, starlark.String(field.Key))
+	}
+	return keys
+}
+
+func (d *FieldDict) Iterate() starlark.Iterator {
+	return &keyIterator{keys: d.Keys()}
+}
+
+func (d *FieldDict) Delete(key starlark.Value) (starlark.Value, bool, error) {
+	if d.m.frozen {
+		return nil, false, errors.New("cannot modify frozen metric")
+	}
+	v, found, err := d.Get(key)
+	if err != nil || !found {
+		return v, found, err
+	}
+	d.m.metric.RemoveField(string(key.(starlark.String)))
+	return v, true, nil
+}
+
+func (d *FieldDict) Clear() error {
+	if d.m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	for _, key := range d.Keys() {
+		d.m.metric.RemoveField(string(key.(starlark.String)))
+	}
+	return nil
+}
+
+// asStarlarkValue converts a field value to a Starlark value.
+func asStarlarkValue(value interface{}) (starlark.Value, error) {
+	switch v := value.(type) {
+	case float64:
+		return starlark.Float(v), nil
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case uint64:
+		return starlark.MakeUint64(v), nil
+	case string:
+		return starlark.String(v), nil
+	case bool:
+		return starlark.Bool(v), nil
+	}
+	return nil, fmt.Errorf("invalid type %T", value)
+}
+
+// asGoValue converts a Starlark value to a field value.
+func asGoValue(value starlark.Value) (interface{}, error) {
+	switch v := value.(type) {
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		if u, ok := v.Uint64(); ok {
+			return u, nil
+		}
+		return nil, errors.New("type error: int out of range")
+	case starlark.String:
+		return v.GoString(), nil
+	case starlark.Bool:
+		return bool(v), nil
+	}
+	return nil, fmt.Errorf("type error: field value must be a float, int, string or bool, got %s", value.Type())
+}
+
+func dictString(d metricDict) string {
+	dict := starlark.NewDict(d.Len())
+	for _, item := range d.Items() {
+		dict.SetKey(item[0], item[1])
+	}
+	return dict.String()
+}
+
+type keyIterator struct {
+	keys []starlark.Value
+}
+
+func (it *keyIterator) Next(p *starlark.Value) bool {
+	if len(it.keys) == 0 {
+		return false
+	}
+	*p = it.keys[0]
+	it.keys = it.keys[1:]
+	return true
+}
+
+func (it *keyIterator) Done() {
+}
+
+type dictMethod func(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error)
+
+var dictMethods = map[string]dictMethod{
+	"clear":      dictClear,
+	"get":        dictGet,
+	"items":      dictItems,
+	"keys":       dictKeys,
+	"pop":        dictPop,
+	"popitem":    dictPopitem,
+	"setdefault": dictSetdefault,
+	"update":     dictUpdate,
+	"values":     dictValues,
+}
+
+var dictMethodNames = func() []string {
+	names := make([]string, 0, len(dictMethods))
+	for name := range dictMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+func dictAttr(d metricDict, name string) (starlark.Value, error) {
+	method, ok := dictMethods[name]
+	if !ok {
+		return nil, nil
+	}
+	b := starlark.NewBuiltin(name, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		return method(b.Receiver().(metricDict), b, args, kwargs)
+	})
+	return b.BindReceiver(d), nil
+}
+
+func dictClear(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	return starlark.None, d.Clear()
+}
+
+func dictGet(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	v, found, err := d.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return v, nil
+	}
+	if dflt != nil {
+		return dflt, nil
+	}
+	return starlark.None, nil
+}
+
+func dictItems(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := d.Items()
+	elems := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		elems = append(elems, item)
+	}
+	return starlark.NewList(elems), nil
+}
+
+func dictKeys(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	return starlark.NewList(d.Keys()), nil
+}
+
+func dictValues(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := d.Items()
+	elems := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		elems = append(elems, item[1])
+	}
+	return starlark.NewList(elems), nil
+}
+
+func dictPop(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	v, found, err := d.Delete(key)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return v, nil
+	}
+	if dflt != nil {
+		return dflt, nil
+	}
+	return nil, fmt.Errorf("%s: missing key %s", b.Name(), key)
+}
+
+func dictPopitem(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := d.Items()
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%s: empty dict", b.Name())
+	}
+	if _, _, err := d.Delete(items[0][0]); err != nil {
+		return nil, err
+	}
+	return items[0], nil
+}
+
+func dictSetdefault(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key starlark.Value
+	var dflt starlark.Value = starlark.None
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	v, found, err := d.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return v, nil
+	}
+	if err := d.SetKey(key, dflt); err != nil {
+		return nil, err
+	}
+	return dflt, nil
+}
+
+func dictUpdate(d metricDict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("%s: got %d arguments, want at most 1", b.Name(), len(args))
+	}
+	if len(args) == 1 {
+		switch v := args[0].(type) {
+		case starlark.IterableMapping:
+			for _, item := range v.Items() {
+				if err := d.SetKey(item[0], item[1]); err != nil {
+					return nil, err
+				}
+			}
+		case starlark.Iterable:
+			iter := v.Iterate()
+			defer iter.Done()
+			var pair starlark.Value
+			for iter.Next(&pair) {
+				tuple, ok := pair.(starlark.Tuple)
+				if !ok || len(tuple) != 2 {
+					return nil, fmt.Errorf("%s: expected pairs of key and value", b.Name())
+				}
+				if err := d.SetKey(tuple[0], tuple[1]); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s: got %s, want iterable", b.Name(), v.Type())
+		}
+	}
+	for _, kwarg := range kwargs {
+		if err := d.SetKey(kwarg[0], kwarg[1]); err != nil {
+			return nil, err
+		}
+	}
+	return starlark.None, nil
+}
@@ -0,0 +1,133 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"go.starlark.net/starlark"
+)
+
+// Metric is the Starlark value of a telegraf.Metric.
+type Metric struct {
+	metric telegraf.Metric
+	frozen bool
+}
+
+func (m *Metric) String() string {
+	var buf strings.Builder
+	buf.WriteString("Metric(")
+	buf.WriteString(starlark.String(m.metric.Name()).String())
+	buf.WriteString(", tags=")
+	buf.WriteString(m.Tags().String())
+	buf.WriteString(", fields=")
+	buf.WriteString(m.Fields().String())
+	buf.WriteString(", time=")
+	buf.WriteString(m.Time().String())
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func (m *Metric) Type() string {
+	return "Metric"
+}
+
+func (m *Metric) Freeze() {
+	m.frozen = true
+}
+
+func (m *Metric) Truth() starlark.Bool {
+	return true
+}
+
+func (m *Metric) Hash() (uint32, error) {
+	return 0, errors.New("unhashable type: Metric")
+}
+
+// AttrNames implements the starlark.HasAttrs interface.
+func (m *Metric) AttrNames() []string {
+	return []string{"name", "tags", "fields", "time"}
+}
+
+// Attr implements the starlark.HasAttrs interface.
+func (m *Metric) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "name":
+		return starlark.String(m.metric.Name()), nil
+	case "tags":
+		return m.Tags(), nil
+	case "fields":
+		return m.Fields(), nil
+	case "time":
+		return m.Time(), nil
+	}
+	return nil, nil
+}
+
+// SetField implements the starlark.HasSetField interface.
+func (m *Metric) SetField(name string, value starlark.Value) error {
+	if m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+
+	switch name {
+	case "name":
+		str, ok := value.(starlark.String)
+		if !ok {
+			return fmt.Errorf("type error: name must be a string, got %s", value.Type())
+		}
+		m.metric.SetName(str.GoString())
+		return nil
+	case "time":
+		i, ok := value.(starlark.Int)
+		if !ok {
+			return fmt.Errorf("type error: time must be an int, got %s", value.Type())
+		}
+		ns, ok := i.Int64()
+		if !ok {
+			return errors.New("type error: time out of range")
+		}
+		m.metric.SetTime(time.Unix(0, ns))
+		return nil
+	case "tags":
+		return replaceItems(m.Tags(), value)
+	case "fields":
+		return replaceItems(m.Fields(), value)
+	}
+	return starlark.NoSuchAttrError(fmt.Sprintf("cannot assign to field %q", name))
+}
+
+// Tags returns the tags of the metric.
+func (m *Metric) Tags() *TagDict {
+	return &TagDict{m}
+}
+
+// Fields returns the fields of the metric.
+func (m *Metric) Fields() *FieldDict {
+	return &FieldDict{m}
+}
+
+// Time returns the time of the metric in nanoseconds since the epoch.
+func (m *Metric) Time() starlark.Int {
+	return starlark.MakeInt64(m.metric.Time().UnixNano())
+}
+
+// replaceItems replaces the items of the dict with the items of value.
+func replaceItems(dict metricDict, value starlark.Value) error {
+	mapping, ok := value.(starlark.IterableMapping)
+	if !ok {
+		return fmt.Errorf("type error: expected mapping, got %s", value.Type())
+	}
+	items := mapping.Items()
+	if err := dict.Clear(); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := dict.SetKey(item[0], item[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
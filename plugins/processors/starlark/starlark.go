@@ -0,0 +1,196 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+)
+
+const sampleConfig = `
+  ## The Starlark source can be set as a string in this configuration file, or
+  ## by referencing a file containing the script.  Only one source or script
+  ## should be set at once.
+  ##
+  ## Source of the Starlark script.
+  source = '''
+def apply(metric):
+	return metric
+'''
+
+  ## File containing a Starlark script.
+  # script = "/usr/local/bin/myscript.star"
+`
+
+type Starlark struct {
+	Source string `toml:"source"`
+	Script string `toml:"script"`
+
+	thread    *starlark.Thread
+	applyFunc *starlark.Function
+	args      starlark.Tuple
+
+	initialized bool
+}
+
+func (s *Starlark) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Starlark) Description() string {
+	return "Process metrics using a Starlark script"
+}
+
+func (s *Starlark) Init() error {
+	s.initialized = true
+	return s.compile()
+}
+
+func (s *Starlark) compile() error {
+	source, err := s.sourceProgram()
+	if err != nil {
+		return err
+	}
+
+	s.thread = &starlark.Thread{
+		Print: func(_ *starlark.Thread, msg string) {
+			log.Printf("I! [processors.starlark] %s", msg)
+		},
+	}
+
+	predeclared := starlark.StringDict{
+		"Metric":   starlark.NewBuiltin("Metric", newMetric),
+		"deepcopy": starlark.NewBuiltin("deepcopy", deepcopy),
+		// state is kept between calls of the apply function
+		"state": starlark.NewDict(0),
+	}
+
+	filename := s.Script
+	if filename == "" {
+		filename = "processors.starlark"
+	}
+	globals, err := starlark.ExecFile(s.thread, filename, source, predeclared)
+	if err != nil {
+		return errorWithBacktrace(err)
+	}
+
+	// Freeze the globals so that values, such as metrics, are not kept
+	// between calls by accident, the state dict is meant for this.
+	globals.Freeze()
+
+	fn, ok := globals["apply"].(*starlark.Function)
+	if !ok {
+		return errors.New("apply function not defined")
+	}
+	if fn.NumParams() != 1 {
+		return errors.New("apply function must take one parameter")
+	}
+	s.applyFunc = fn
+	s.args = make(starlark.Tuple, 1)
+	return nil
+}
+
+// sourceProgram returns the source of the script.
+func (s *Starlark) sourceProgram() (interface{}, error) {
+	switch {
+	case s.Source != "" && s.Script != "":
+		return nil, errors.New("both source and script are set")
+	case s.Source != "":
+		return s.Source, nil
+	case s.Script != "":
+		return ioutil.ReadFile(s.Script)
+	}
+	return nil, errors.New("one of source or script must be set")
+}
+
+func (s *Starlark) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	if !s.initialized {
+		if err := s.Init(); err != nil {
+			log.Printf("E! [processors.starlark] Error initializing: %v", err)
+		}
+	}
+	if s.applyFunc == nil {
+		return metrics
+	}
+
+	results := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		out, err := s.apply(m)
+		if err != nil {
+			log.Printf("E! [processors.starlark] Error in apply: %v", err)
+			m.Reject()
+			continue
+		}
+
+		found := false
+		for _, om := range out {
+			if om == m {
+				found = true
+			}
+		}
+		if !found {
+			m.Drop()
+		}
+		results = append(results, out...)
+	}
+	return results
+}
+
+// apply calls the apply function of the script with the metric and returns
+// the metrics it returned.
+func (s *Starlark) apply(m telegraf.Metric) ([]telegraf.Metric, error) {
+	s.args[0] = &Metric{metric: m}
+	rv, err := starlark.Call(s.thread, s.applyFunc, s.args, nil)
+	s.args[0] = nil
+	if err != nil {
+		return nil, errorWithBacktrace(err)
+	}
+
+	switch rv := rv.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *Metric:
+		return []telegraf.Metric{rv.metric}, nil
+	case *starlark.List:
+		out := make([]telegraf.Metric, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			v, ok := rv.Index(i).(*Metric)
+			if !ok {
+				return nil, fmt.Errorf("apply returned list with %s, expected Metric", rv.Index(i).Type())
+			}
+			for _, om := range out {
+				if om == v.metric {
+					return nil, errors.New("apply returned the same metric more than once, use deepcopy")
+				}
+			}
+			out = append(out, v.metric)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("apply returned %s, expected Metric, list of Metric or None", rv.Type())
+}
+
+func errorWithBacktrace(err error) error {
+	if err, ok := err.(*starlark.EvalError); ok {
+		return errors.New(err.Backtrace())
+	}
+	return err
+}
+
+func init() {
+	// Language features enabled in the scripts.  While loops and recursion
+	// stay disabled, so that a script always terminates.
+	resolve.AllowFloat = true
+	resolve.AllowLambda = true
+	resolve.AllowNestedDef = true
+	resolve.AllowSet = true
+
+	processors.Add("starlark", func() telegraf.Processor {
+		return &Starlark{}
+	})
+}
@@ -0,0 +1,350 @@
+package starlark
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		input    []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "passthrough",
+			source: `
+def apply(metric):
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "rename and set time",
+			source: `
+def apply(metric):
+	metric.name = "processor"
+	metric.time = metric.time + 1000000000
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("processor",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(1, 0),
+				),
+			},
+		},
+		{
+			name: "modify tags and fields",
+			source: `
+def apply(metric):
+	metric.tags["host"] = metric.tags.pop("hostname")
+	metric.tags.setdefault("region", "us-east-1")
+	metric.fields["count"] = len(metric.fields)
+	metric.fields["ratio"] = metric.fields["time_idle"] / 100
+	metric.fields["name"] = metric.name
+	metric.fields["big"] = 18446744073709551615
+	metric.fields["ok"] = "time_idle" in metric.fields
+	metric.fields.pop("time_idle")
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"hostname": "example.org"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{
+						"host":   "example.org",
+						"region": "us-east-1",
+					},
+					map[string]interface{}{
+						"count": int64(1),
+						"ratio": 0.42,
+						"name":  "cpu",
+						"big":   uint64(18446744073709551615),
+						"ok":    true,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "replace tags",
+			source: `
+def apply(metric):
+	metric.tags = {k.upper(): v for k, v in metric.tags.items()}
+	metric.fields.update(a=1, b=2.0)
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"HOST": "example.org"},
+					map[string]interface{}{
+						"time_idle": 42.0,
+						"a":         int64(1),
+						"b":         2.0,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "drop",
+			source: `
+def apply(metric):
+	if metric.tags.get("host") == "drop":
+		return None
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "drop"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "keep"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "keep"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "multiple metrics",
+			source: `
+def apply(metric):
+	dup = deepcopy(metric)
+	dup.name = "dup"
+	total = Metric("total")
+	total.fields["sum"] = 0
+	for v in metric.fields.values():
+		total.fields["sum"] += v
+	total.time = 0
+	return [metric, dup, total]
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"a": 1, "b": 2},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"a": 1, "b": 2},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("dup",
+					map[string]string{},
+					map[string]interface{}{"a": 1, "b": 2},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("total",
+					map[string]string{},
+					map[string]interface{}{"sum": 3},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "state",
+			source: `
+def apply(metric):
+	count = state.get("count", 0) + 1
+	state["count"] = count
+	metric.fields["count"] = count
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"count": 1},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"count": 2},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "error rejects metric",
+			source: `
+def apply(metric):
+	metric.fields["x"] = metric.fields["missing"]
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "same metric returned twice",
+			source: `
+def apply(metric):
+	return [metric, metric]
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Starlark{Source: tt.source}
+			actual := plugin.Apply(tt.input...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *Starlark
+	}{
+		{
+			name:   "no source",
+			plugin: &Starlark{},
+		},
+		{
+			name: "both source and script",
+			plugin: &Starlark{
+				Source: "def apply(metric):\n\treturn metric\n",
+				Script: "testdata/script.star",
+			},
+		},
+		{
+			name:   "syntax error",
+			plugin: &Starlark{Source: "def apply(metric)\n\treturn metric\n"},
+		},
+		{
+			name:   "no apply function",
+			plugin: &Starlark{Source: "x = 1\n"},
+		},
+		{
+			name:   "apply with two parameters",
+			plugin: &Starlark{Source: "def apply(a, b):\n\treturn a\n"},
+		},
+		{
+			name:   "while loop",
+			plugin: &Starlark{Source: "def apply(metric):\n\twhile True:\n\t\tpass\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.plugin.Init())
+		})
+	}
+}
+
+func TestFrozenGlobals(t *testing.T) {
+	plugin := &Starlark{Source: `
+last = []
+
+def apply(metric):
+	last.append(metric)
+	return metric
+`}
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"time_idle": 42.0},
+		time.Unix(0, 0),
+	)
+	actual := plugin.Apply(m)
+	require.Len(t, actual, 0)
+}
+
+func TestScript(t *testing.T) {
+	f, err := ioutil.TempFile("", "script.star")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("def apply(metric):\n\tmetric.tags['script'] = 'yes'\n\treturn metric\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	plugin := &Starlark{Script: f.Name()}
+	actual := plugin.Apply(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"time_idle": 42.0},
+		time.Unix(0, 0),
+	))
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"script": "yes"},
+			map[string]interface{}{"time_idle": 42.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
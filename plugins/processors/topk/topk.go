@@ -137,6 +137,10 @@ func (t *TopK) Description() string {
 	return "Print all metrics that pass through this filter."
 }
 
+// SingleWorker implements processors.SingleWorker, the top series are
+// computed over all the metrics.
+func (t *TopK) SingleWorker() {}
+
 func (t *TopK) generateGroupByKey(m telegraf.Metric) (string, error) {
 	// Create the filter.Filter objects if they have not been created
 	if t.tagsGlobs == nil && len(t.GroupBy) > 0 {
@@ -0,0 +1,69 @@
+package unpivot
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Tag to use for the name.
+  tag_key = "name"
+  ## Field to use for the value.
+  value_key = "value"
+`
+
+type Unpivot struct {
+	TagKey   string `toml:"tag_key"`
+	ValueKey string `toml:"value_key"`
+}
+
+func (p *Unpivot) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Unpivot) Description() string {
+	return "Rotate multi field metric into several single field metrics"
+}
+
+func (p *Unpivot) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	fieldCount := 0
+	for _, m := range metrics {
+		fieldCount += len(m.FieldList())
+	}
+
+	results := make([]telegraf.Metric, 0, fieldCount)
+	for _, m := range metrics {
+		base := copyWithoutFields(m)
+		for _, field := range m.FieldList() {
+			metric := base.Copy()
+			metric.AddTag(p.TagKey, field.Key)
+			metric.AddField(p.ValueKey, field.Value)
+			if meta, ok := m.GetFieldMeta(field.Key); ok {
+				metric.SetFieldMeta(p.ValueKey, meta)
+			}
+			results = append(results, metric)
+		}
+		base.Drop()
+		m.Drop()
+	}
+	return results
+}
+
+// copyWithoutFields returns a copy of the metric with its fields removed.
+func copyWithoutFields(m telegraf.Metric) telegraf.Metric {
+	dup := m.Copy()
+	keys := make([]string, 0, len(dup.FieldList()))
+	for _, field := range dup.FieldList() {
+		keys = append(keys, field.Key)
+	}
+	for _, key := range keys {
+		dup.RemoveField(key)
+	}
+	return dup
+}
+
+func init() {
+	processors.Add("unpivot", func() telegraf.Processor {
+		return &Unpivot{}
+	})
+}
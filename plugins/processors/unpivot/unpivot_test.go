@@ -0,0 +1,101 @@
+package unpivot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnpivot(t *testing.T) {
+	tests := []struct {
+		name     string
+		unpivot  *Unpivot
+		metrics  []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "simple",
+			unpivot: &Unpivot{
+				TagKey:   "name",
+				ValueKey: "value",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"idle_time": int64(42)},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"name": "idle_time"},
+					map[string]interface{}{"value": int64(42)},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "multi fields",
+			unpivot: &Unpivot{
+				TagKey:   "name",
+				ValueKey: "value",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a"},
+					map[string]interface{}{
+						"idle_time": int64(42),
+						"idle_user": int64(43),
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a", "name": "idle_time"},
+					map[string]interface{}{"value": int64(42)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a", "name": "idle_user"},
+					map[string]interface{}{"value": int64(43)},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.unpivot.Apply(tt.metrics...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestUnpivotTracking(t *testing.T) {
+	var delivered []telegraf.DeliveryInfo
+	m, _ := metric.WithTracking(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"idle_time": int64(42),
+			"idle_user": int64(43),
+		},
+		time.Unix(0, 0),
+	), func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info)
+	})
+
+	unpivot := &Unpivot{TagKey: "name", ValueKey: "value"}
+	actual := unpivot.Apply(m)
+	require.Len(t, actual, 2)
+
+	actual[0].Accept()
+	require.Len(t, delivered, 0)
+	actual[1].Accept()
+	require.Len(t, delivered, 1)
+	require.True(t, delivered[0].Delivered())
+}
@@ -47,12 +47,27 @@ func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	return serialized, nil
 }
 
+type fieldMeta struct {
+	Unit        string `json:"unit,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
 func (s *serializer) createObject(metric telegraf.Metric) map[string]interface{} {
-	m := make(map[string]interface{}, 4)
+	m := make(map[string]interface{}, 5)
 	m["tags"] = metric.Tags()
 	m["fields"] = metric.Fields()
 	m["name"] = metric.Name()
 	m["timestamp"] = metric.Time().UnixNano() / int64(s.TimestampUnits)
+
+	meta := make(map[string]fieldMeta)
+	for _, field := range metric.FieldList() {
+		if fm, ok := metric.GetFieldMeta(field.Key); ok {
+			meta[field.Key] = fieldMeta{Unit: fm.Unit, Description: fm.Description}
+		}
+	}
+	if len(meta) > 0 {
+		m["field_meta"] = meta
+	}
 	return m
 }
 
@@ -38,6 +38,26 @@ func TestSerializeMetricFloat(t *testing.T) {
 	assert.Equal(t, string(expS), string(buf))
 }
 
+func TestSerializeFieldMeta(t *testing.T) {
+	now := time.Now()
+	m, err := metric.New("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{
+			"usage_idle":   float64(91.5),
+			"usage_system": float64(2.5),
+		},
+		now)
+	assert.NoError(t, err)
+	m.SetFieldMeta("usage_idle", telegraf.FieldMeta{Unit: "percent", Description: "Idle time"})
+	m.SetFieldMeta("usage_system", telegraf.FieldMeta{Unit: "percent"})
+
+	s, _ := NewSerializer(0)
+	buf, err := s.Serialize(m)
+	assert.NoError(t, err)
+	expS := fmt.Sprintf(`{"field_meta":{"usage_idle":{"unit":"percent","description":"Idle time"},"usage_system":{"unit":"percent"}},"fields":{"usage_idle":91.5,"usage_system":2.5},"name":"cpu","tags":{"cpu":"cpu0"},"timestamp":%d}`, now.Unix()) + "\n"
+	assert.Equal(t, expS, string(buf))
+}
+
 func TestSerialize_TimestampUnits(t *testing.T) {
 	tests := []struct {
 		name           string
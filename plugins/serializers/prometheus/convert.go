@@ -0,0 +1,63 @@
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// The conversion of metric names, label names and field metadata is shared
+// with the prometheus_client output and the prometheusremotewrite serializer.
+
+var (
+	invalidNameCharRE = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	validNameCharRE   = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+)
+
+// Sanitize replaces the characters that are not valid in a metric or label
+// name with "_".
+func Sanitize(value string) string {
+	return invalidNameCharRE.ReplaceAllString(value, "_")
+}
+
+// IsValidMetricName reports if the name is a valid metric name.
+func IsValidMetricName(name string) bool {
+	return validNameCharRE.MatchString(name)
+}
+
+// IsValidLabelName reports if the name is a valid label name, unlike metric
+// names these cannot contain colons.
+func IsValidLabelName(name string) bool {
+	return validNameCharRE.MatchString(name) && !strings.Contains(name, ":")
+}
+
+// HelpText returns the HELP text from the metadata of the first of the fields
+// with a unit or description.
+func HelpText(metric telegraf.Metric, fields ...string) string {
+	for _, fn := range fields {
+		meta, ok := metric.GetFieldMeta(fn)
+		if !ok {
+			continue
+		}
+		switch {
+		case meta.Description != "" && meta.Unit != "":
+			return fmt.Sprintf("%s (%s)", meta.Description, meta.Unit)
+		case meta.Description != "":
+			return meta.Description
+		case meta.Unit != "":
+			return fmt.Sprintf("Telegraf collected metric (%s)", meta.Unit)
+		}
+	}
+	return ""
+}
+
+// FieldKeys returns the keys of the fields of the metric.
+func FieldKeys(metric telegraf.Metric) []string {
+	keys := make([]string, 0, len(metric.FieldList()))
+	for _, field := range metric.FieldList() {
+		keys = append(keys, field.Key)
+	}
+	return keys
+}
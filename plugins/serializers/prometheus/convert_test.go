@@ -0,0 +1,40 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitize(t *testing.T) {
+	require.Equal(t, "foo_bar:colon", Sanitize("foo.bar:colon"))
+	require.Equal(t, "tag_with_dash", Sanitize("tag-with-dash"))
+}
+
+func TestIsValidName(t *testing.T) {
+	require.True(t, IsValidMetricName("foo_bar:colon"))
+	require.True(t, IsValidMetricName(":foo"))
+	require.False(t, IsValidMetricName("0foo"))
+
+	require.True(t, IsValidLabelName("foo_bar"))
+	require.False(t, IsValidLabelName("foo:bar"))
+	require.False(t, IsValidLabelName("0foo"))
+}
+
+func TestHelpText(t *testing.T) {
+	m := testutil.MustMetric("mem",
+		map[string]string{},
+		map[string]interface{}{"free": 1, "used": 2, "total": 3},
+		time.Unix(0, 0),
+	)
+	m.SetFieldMeta("used", telegraf.FieldMeta{Unit: "bytes", Description: "Used memory"})
+	m.SetFieldMeta("total", telegraf.FieldMeta{Unit: "bytes"})
+
+	require.Equal(t, "", HelpText(m, "free"))
+	require.Equal(t, "Used memory (bytes)", HelpText(m, "free", "used"))
+	require.Equal(t, "Telegraf collected metric (bytes)", HelpText(m, "total"))
+	require.ElementsMatch(t, []string{"free", "used", "total"}, FieldKeys(m))
+}
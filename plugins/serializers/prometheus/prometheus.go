@@ -0,0 +1,313 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/telegraf"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// FormatConfig are the options of the serializer.
+type FormatConfig struct {
+	// ExportTimestamp adds the time of the metric to the samples.
+	ExportTimestamp bool
+	// StringAsLabel adds the string fields of the metric as labels.
+	StringAsLabel bool
+}
+
+// Serializer writes metrics in the Prometheus text exposition format.
+type Serializer struct {
+	config FormatConfig
+}
+
+func NewSerializer(config FormatConfig) (*Serializer, error) {
+	return &Serializer{config: config}, nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch writes the metrics grouped by metric family, if there is
+// more than one metric of a series only the latest is written.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	coll := newCollection(s.config)
+	for _, metric := range sorted(metrics) {
+		coll.add(metric)
+	}
+
+	var buf bytes.Buffer
+	for _, mf := range coll.families() {
+		if _, err := expfmt.MetricFamilyToText(&buf, mf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// family is a metric family and its samples by their labels.
+type family struct {
+	mf      *dto.MetricFamily
+	samples map[string]*dto.Metric
+}
+
+type collection struct {
+	config FormatConfig
+	fam    map[string]*family
+}
+
+func newCollection(config FormatConfig) *collection {
+	return &collection{
+		config: config,
+		fam:    make(map[string]*family),
+	}
+}
+
+func (c *collection) add(metric telegraf.Metric) {
+	labels := c.labels(metric)
+
+	switch metric.Type() {
+	case telegraf.Summary:
+		summary := &dto.Summary{}
+		for _, field := range metric.FieldList() {
+			value, ok := toFloat(field.Value)
+			if !ok {
+				continue
+			}
+			switch field.Key {
+			case "sum":
+				summary.SampleSum = proto.Float64(value)
+			case "count":
+				summary.SampleCount = proto.Uint64(uint64(value))
+			default:
+				quantile, err := strconv.ParseFloat(field.Key, 64)
+				if err != nil {
+					continue
+				}
+				summary.Quantile = append(summary.Quantile, &dto.Quantile{
+					Quantile: proto.Float64(quantile),
+					Value:    proto.Float64(value),
+				})
+			}
+		}
+		sort.Slice(summary.Quantile, func(i, j int) bool {
+			return summary.Quantile[i].GetQuantile() < summary.Quantile[j].GetQuantile()
+		})
+		c.addSample(metric, Sanitize(metric.Name()), HelpText(metric, FieldKeys(metric)...),
+			&dto.Metric{Label: labels, Summary: summary})
+	case telegraf.Histogram:
+		histogram := &dto.Histogram{}
+		for _, field := range metric.FieldList() {
+			value, ok := toFloat(field.Value)
+			if !ok {
+				continue
+			}
+			switch field.Key {
+			case "sum":
+				histogram.SampleSum = proto.Float64(value)
+			case "count":
+				histogram.SampleCount = proto.Uint64(uint64(value))
+			default:
+				bound, err := strconv.ParseFloat(field.Key, 64)
+				if err != nil {
+					continue
+				}
+				histogram.Bucket = append(histogram.Bucket, &dto.Bucket{
+					UpperBound:      proto.Float64(bound),
+					CumulativeCount: proto.Uint64(uint64(value)),
+				})
+			}
+		}
+		sort.Slice(histogram.Bucket, func(i, j int) bool {
+			return histogram.Bucket[i].GetUpperBound() < histogram.Bucket[j].GetUpperBound()
+		})
+		c.addSample(metric, Sanitize(metric.Name()), HelpText(metric, FieldKeys(metric)...),
+			&dto.Metric{Label: labels, Histogram: histogram})
+	default:
+		for _, field := range metric.FieldList() {
+			value, ok := toFloat(field.Value)
+			if !ok {
+				continue
+			}
+
+			// Special handling of value field; supports passthrough from
+			// the prometheus input.
+			var name string
+			switch {
+			case metric.Type() == telegraf.Counter && field.Key == "counter",
+				metric.Type() == telegraf.Gauge && field.Key == "gauge",
+				field.Key == "value":
+				name = Sanitize(metric.Name())
+			default:
+				name = Sanitize(fmt.Sprintf("%s_%s", metric.Name(), field.Key))
+			}
+
+			sample := &dto.Metric{Label: labels}
+			switch metric.Type() {
+			case telegraf.Counter:
+				sample.Counter = &dto.Counter{Value: proto.Float64(value)}
+			case telegraf.Gauge:
+				sample.Gauge = &dto.Gauge{Value: proto.Float64(value)}
+			default:
+				sample.Untyped = &dto.Untyped{Value: proto.Float64(value)}
+			}
+			c.addSample(metric, name, HelpText(metric, field.Key), sample)
+		}
+	}
+}
+
+// addSample adds the sample to the family with the name, replacing the
+// sample of the family with the same labels.  Samples of a type other than
+// the type of the family are ignored.
+func (c *collection) addSample(metric telegraf.Metric, name string, help string, sample *dto.Metric) {
+	if !IsValidMetricName(name) {
+		return
+	}
+
+	fam, ok := c.fam[name]
+	if !ok {
+		fam = &family{
+			mf: &dto.MetricFamily{
+				Name: proto.String(name),
+				Type: metricType(metric.Type()).Enum(),
+			},
+			samples: make(map[string]*dto.Metric),
+		}
+		c.fam[name] = fam
+	}
+	if fam.mf.GetType() != metricType(metric.Type()) {
+		return
+	}
+	if help != "" {
+		fam.mf.Help = proto.String(help)
+	}
+
+	if c.config.ExportTimestamp {
+		sample.TimestampMs = proto.Int64(metric.Time().UnixNano() / int64(1000000))
+	}
+	fam.samples[labelsID(sample.Label)] = sample
+}
+
+// families returns the metric families sorted by name, with their samples
+// sorted by labels.
+func (c *collection) families() []*dto.MetricFamily {
+	names := make([]string, 0, len(c.fam))
+	for name := range c.fam {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		fam := c.fam[name]
+		ids := make([]string, 0, len(fam.samples))
+		for id := range fam.samples {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		fam.mf.Metric = make([]*dto.Metric, 0, len(ids))
+		for _, id := range ids {
+			fam.mf.Metric = append(fam.mf.Metric, fam.samples[id])
+		}
+		families = append(families, fam.mf)
+	}
+	return families
+}
+
+// labels returns the labels of the metric sorted by name, tags with a name
+// that is not valid are ignored.
+func (c *collection) labels(metric telegraf.Metric) []*dto.LabelPair {
+	labels := make(map[string]string)
+	for _, tag := range metric.TagList() {
+		name := Sanitize(tag.Key)
+		if !IsValidLabelName(name) {
+			continue
+		}
+		labels[name] = tag.Value
+	}
+
+	// Prometheus doesn't have a string value type, so convert string
+	// fields to labels if enabled.
+	if c.config.StringAsLabel {
+		for _, field := range metric.FieldList() {
+			value, ok := field.Value.(string)
+			if !ok {
+				continue
+			}
+			name := Sanitize(field.Key)
+			if !IsValidLabelName(name) {
+				continue
+			}
+			labels[name] = value
+		}
+	}
+
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, &dto.LabelPair{
+			Name:  proto.String(name),
+			Value: proto.String(value),
+		})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].GetName() < pairs[j].GetName()
+	})
+	return pairs
+}
+
+func labelsID(labels []*dto.LabelPair) string {
+	pairs := make([]string, 0, len(labels))
+	for _, lp := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", lp.GetName(), lp.GetValue()))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func metricType(tt telegraf.ValueType) dto.MetricType {
+	switch tt {
+	case telegraf.Counter:
+		return dto.MetricType_COUNTER
+	case telegraf.Gauge:
+		return dto.MetricType_GAUGE
+	case telegraf.Summary:
+		return dto.MetricType_SUMMARY
+	case telegraf.Histogram:
+		return dto.MetricType_HISTOGRAM
+	default:
+		return dto.MetricType_UNTYPED
+	}
+}
+
+// sorted returns a copy of the metrics in time ascending order, so that the
+// latest metric of a series is added last.
+func sorted(metrics []telegraf.Metric) []telegraf.Metric {
+	batch := make([]telegraf.Metric, len(metrics))
+	copy(batch, metrics)
+	sort.SliceStable(batch, func(i, j int) bool {
+		return batch[i].Time().Before(batch[j].Time())
+	})
+	return batch
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		if math.IsNaN(v) {
+			return 0, false
+		}
+		return v, true
+	}
+	return 0, false
+}
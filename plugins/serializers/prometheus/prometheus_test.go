@@ -0,0 +1,294 @@
+package prometheus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMetric(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+	tp telegraf.ValueType,
+) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, tm, tp)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestSerializeBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   FormatConfig
+		metrics  []telegraf.Metric
+		expected string
+	}{
+		{
+			name: "untyped fields",
+			metrics: []telegraf.Metric{
+				mustMetric("cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{
+						"time_idle": 42.5,
+						"time_user": 43.5,
+						"state":     "ok",
+						"valid":     true,
+					},
+					time.Unix(0, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: `
+# TYPE cpu_time_idle untyped
+cpu_time_idle{host="example.org"} 42.5
+# TYPE cpu_time_user untyped
+cpu_time_user{host="example.org"} 43.5
+`,
+		},
+		{
+			name: "value field",
+			metrics: []telegraf.Metric{
+				mustMetric("uptime",
+					map[string]string{},
+					map[string]interface{}{"value": 3600.5},
+					time.Unix(0, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: `
+# TYPE uptime untyped
+uptime 3600.5
+`,
+		},
+		{
+			name: "counter and gauge from prometheus input",
+			metrics: []telegraf.Metric{
+				mustMetric("http_requests_total",
+					map[string]string{"code": "200"},
+					map[string]interface{}{"counter": 1027.5},
+					time.Unix(0, 0),
+					telegraf.Counter,
+				),
+				mustMetric("go_goroutines",
+					map[string]string{},
+					map[string]interface{}{"gauge": 15.5},
+					time.Unix(0, 0),
+					telegraf.Gauge,
+				),
+			},
+			expected: `
+# TYPE go_goroutines gauge
+go_goroutines 15.5
+# TYPE http_requests_total counter
+http_requests_total{code="200"} 1027.5
+`,
+		},
+		{
+			name: "samples of a family are grouped",
+			metrics: []telegraf.Metric{
+				mustMetric("cpu",
+					map[string]string{"cpu": "cpu1"},
+					map[string]interface{}{"time_idle": 43.5},
+					time.Unix(0, 0),
+					telegraf.Untyped,
+				),
+				mustMetric("mem",
+					map[string]string{},
+					map[string]interface{}{"free": 1.5},
+					time.Unix(0, 0),
+					telegraf.Untyped,
+				),
+				mustMetric("cpu",
+					map[string]string{"cpu": "cpu0"},
+					map[string]interface{}{"time_idle": 42.5},
+					time.Unix(0, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: `
+# TYPE cpu_time_idle untyped
+cpu_time_idle{cpu="cpu0"} 42.5
+cpu_time_idle{cpu="cpu1"} 43.5
+# TYPE mem_free untyped
+mem_free 1.5
+`,
+		},
+		{
+			name: "latest sample of a series is kept",
+			metrics: []telegraf.Metric{
+				mustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 43.5},
+					time.Unix(1, 0),
+					telegraf.Untyped,
+				),
+				mustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.5},
+					time.Unix(0, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: `
+# TYPE cpu_time_idle untyped
+cpu_time_idle 43.5
+`,
+		},
+		{
+			name: "names are sanitized",
+			metrics: []telegraf.Metric{
+				mustMetric("disk io",
+					map[string]string{"device-name": "sda", "1st": "ignored"},
+					map[string]interface{}{"reads.total": 1.5},
+					time.Unix(0, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: `
+# TYPE disk_io_reads_total untyped
+disk_io_reads_total{device_name="sda"} 1.5
+`,
+		},
+		{
+			name:   "export timestamp",
+			config: FormatConfig{ExportTimestamp: true},
+			metrics: []telegraf.Metric{
+				mustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.5},
+					time.Unix(1490802350, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: `
+# TYPE cpu_time_idle untyped
+cpu_time_idle 42.5 1490802350000
+`,
+		},
+		{
+			name:   "string as label",
+			config: FormatConfig{StringAsLabel: true},
+			metrics: []telegraf.Metric{
+				mustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.5, "state": "ok"},
+					time.Unix(0, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: `
+# TYPE cpu_time_idle untyped
+cpu_time_idle{state="ok"} 42.5
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.config)
+			require.NoError(t, err)
+			actual, err := s.SerializeBatch(tt.metrics)
+			require.NoError(t, err)
+			require.Equal(t, strings.TrimSpace(tt.expected), strings.TrimSpace(string(actual)))
+		})
+	}
+}
+
+// Test that the histograms and summaries are parsed back into the same
+// metrics by the prometheus parser.
+func TestSerializeRoundTrip(t *testing.T) {
+	metrics := []telegraf.Metric{
+		mustMetric("request_size_bytes",
+			map[string]string{"path": "/"},
+			map[string]interface{}{
+				"2.5":   5.0,
+				"0.25":  3.0,
+				"+Inf":  6.0,
+				"sum":   4200.5,
+				"count": 6.0,
+			},
+			time.Unix(1490802350, 0),
+			telegraf.Histogram,
+		),
+		mustMetric("rpc_duration_seconds",
+			map[string]string{},
+			map[string]interface{}{
+				"0.9":   0.0031,
+				"0.5":   0.0012,
+				"sum":   1.5,
+				"count": 100.0,
+			},
+			time.Unix(1490802350, 0),
+			telegraf.Summary,
+		),
+	}
+
+	s, err := NewSerializer(FormatConfig{ExportTimestamp: true})
+	require.NoError(t, err)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	actual, err := prometheus.NewParser(nil).Parse(buf)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, metrics, actual)
+	for i := range actual {
+		require.Equal(t, metrics[i].Type(), actual[i].Type())
+	}
+	require.Contains(t, string(buf), `request_size_bytes_bucket{path="/",le="0.25"}`)
+}
+
+func TestSerializeIntegers(t *testing.T) {
+	m := mustMetric("disk",
+		map[string]string{},
+		map[string]interface{}{
+			"reads":  int64(42),
+			"writes": uint64(43),
+		},
+		time.Unix(0, 0),
+		telegraf.Counter,
+	)
+
+	s, err := NewSerializer(FormatConfig{})
+	require.NoError(t, err)
+	actual, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(actual))
+	require.NoError(t, err)
+	require.Len(t, families, 2)
+	require.Equal(t, 42.0, families["disk_reads"].Metric[0].GetCounter().GetValue())
+	require.Equal(t, 43.0, families["disk_writes"].Metric[0].GetCounter().GetValue())
+}
+
+func TestSerializeHelp(t *testing.T) {
+	m := mustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"time_idle": 42.5},
+		time.Unix(0, 0),
+		telegraf.Counter,
+	)
+	m.SetFieldMeta("time_idle", telegraf.FieldMeta{Unit: "seconds", Description: "Time spent idle"})
+
+	s, err := NewSerializer(FormatConfig{})
+	require.NoError(t, err)
+	actual, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	expected := `# HELP cpu_time_idle Time spent idle (seconds)
+# TYPE cpu_time_idle counter
+cpu_time_idle 42.5
+`
+	require.Equal(t, expected, string(actual))
+}
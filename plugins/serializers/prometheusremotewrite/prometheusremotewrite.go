@@ -0,0 +1,248 @@
+package prometheusremotewrite
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/prompb"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+)
+
+// FormatConfig are the options of the serializer.
+type FormatConfig struct {
+	// StringAsLabel adds the string fields of the metric as labels.
+	StringAsLabel bool
+}
+
+// Serializer writes metrics as the snappy compressed protocol buffer body
+// of a Prometheus remote write request.
+type Serializer struct {
+	config FormatConfig
+}
+
+func NewSerializer(config FormatConfig) (*Serializer, error) {
+	return &Serializer{config: config}, nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch writes the metrics as a single write request, with the
+// samples of each series in time ascending order.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	bySeries := make(map[string]*prompb.TimeSeries)
+	for _, metric := range metrics {
+		labels := s.labels(metric)
+		ts := metric.Time().UnixNano() / int64(1000000)
+		for _, sample := range samples(metric) {
+			if !prometheus.IsValidMetricName(sample.name) {
+				continue
+			}
+
+			sampleLabels := make([]*prompb.Label, 0, len(labels)+2)
+			sampleLabels = append(sampleLabels, &prompb.Label{Name: "__name__", Value: sample.name})
+			sampleLabels = append(sampleLabels, labels...)
+			if sample.label != nil {
+				sampleLabels = append(sampleLabels, sample.label)
+			}
+			sort.Slice(sampleLabels, func(i, j int) bool {
+				return sampleLabels[i].Name < sampleLabels[j].Name
+			})
+
+			id := labelsID(sampleLabels)
+			series, ok := bySeries[id]
+			if !ok {
+				series = &prompb.TimeSeries{Labels: sampleLabels}
+				bySeries[id] = series
+			}
+			series.Samples = append(series.Samples, &prompb.Sample{Value: sample.value, Timestamp: ts})
+		}
+	}
+
+	ids := make([]string, 0, len(bySeries))
+	for id := range bySeries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	req := &prompb.WriteRequest{
+		Timeseries: make([]*prompb.TimeSeries, 0, len(ids)),
+	}
+	for _, id := range ids {
+		series := bySeries[id]
+		sort.SliceStable(series.Samples, func(i, j int) bool {
+			return series.Samples[i].Timestamp < series.Samples[j].Timestamp
+		})
+		req.Timeseries = append(req.Timeseries, series)
+	}
+
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, data), nil
+}
+
+// sample is a value of the metric, with the name of its series and the
+// quantile or bucket label if any.
+type sample struct {
+	name  string
+	label *prompb.Label
+	value float64
+}
+
+// samples returns the samples of the metric, named the same way as by the
+// prometheus serializer.
+func samples(metric telegraf.Metric) []sample {
+	name := prometheus.Sanitize(metric.Name())
+
+	var samples []sample
+	switch metric.Type() {
+	case telegraf.Summary, telegraf.Histogram:
+		var hasInf bool
+		var count float64
+		for _, field := range metric.FieldList() {
+			value, ok := toFloat(field.Value)
+			if !ok {
+				continue
+			}
+			switch field.Key {
+			case "sum":
+				samples = append(samples, sample{name: name + "_sum", value: value})
+			case "count":
+				count = value
+				samples = append(samples, sample{name: name + "_count", value: value})
+			default:
+				bound, err := strconv.ParseFloat(field.Key, 64)
+				if err != nil {
+					continue
+				}
+				if metric.Type() == telegraf.Summary {
+					samples = append(samples, sample{
+						name:  name,
+						label: &prompb.Label{Name: "quantile", Value: formatFloat(bound)},
+						value: value,
+					})
+					continue
+				}
+				hasInf = hasInf || math.IsInf(bound, +1)
+				samples = append(samples, sample{
+					name:  name + "_bucket",
+					label: &prompb.Label{Name: "le", Value: formatFloat(bound)},
+					value: value,
+				})
+			}
+		}
+		// the +Inf bucket is required, it is the count of the histogram
+		if metric.Type() == telegraf.Histogram && !hasInf {
+			samples = append(samples, sample{
+				name:  name + "_bucket",
+				label: &prompb.Label{Name: "le", Value: "+Inf"},
+				value: count,
+			})
+		}
+	default:
+		for _, field := range metric.FieldList() {
+			value, ok := toFloat(field.Value)
+			if !ok {
+				continue
+			}
+
+			// Special handling of value field; supports passthrough from
+			// the prometheus input.
+			switch {
+			case metric.Type() == telegraf.Counter && field.Key == "counter",
+				metric.Type() == telegraf.Gauge && field.Key == "gauge",
+				field.Key == "value":
+				samples = append(samples, sample{name: name, value: value})
+			default:
+				samples = append(samples, sample{
+					name:  prometheus.Sanitize(fmt.Sprintf("%s_%s", metric.Name(), field.Key)),
+					value: value,
+				})
+			}
+		}
+	}
+	return samples
+}
+
+// labels returns the labels of the metric, tags with a name that is not
+// valid are ignored.
+func (s *Serializer) labels(metric telegraf.Metric) []*prompb.Label {
+	labels := make(map[string]string)
+	for _, tag := range metric.TagList() {
+		name := prometheus.Sanitize(tag.Key)
+		if !isValidLabelName(name) {
+			continue
+		}
+		labels[name] = tag.Value
+	}
+
+	// Prometheus doesn't have a string value type, so convert string
+	// fields to labels if enabled.
+	if s.config.StringAsLabel {
+		for _, field := range metric.FieldList() {
+			value, ok := field.Value.(string)
+			if !ok {
+				continue
+			}
+			name := prometheus.Sanitize(field.Key)
+			if !isValidLabelName(name) {
+				continue
+			}
+			labels[name] = value
+		}
+	}
+
+	pairs := make([]*prompb.Label, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, &prompb.Label{Name: name, Value: value})
+	}
+	return pairs
+}
+
+func labelsID(labels []*prompb.Label) string {
+	pairs := make([]string, 0, len(labels))
+	for _, lp := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", lp.Name, lp.Value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// isValidLabelName reports if the name is a valid label name, names starting
+// with __ are reserved.
+func isValidLabelName(name string) bool {
+	return prometheus.IsValidLabelName(name) && !strings.HasPrefix(name, "__")
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		if math.IsNaN(v) {
+			return 0, false
+		}
+		return v, true
+	}
+	return 0, false
+}
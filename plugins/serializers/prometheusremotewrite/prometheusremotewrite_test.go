@@ -0,0 +1,193 @@
+package prometheusremotewrite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/prompb"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMetric(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+	tp telegraf.ValueType,
+) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, tm, tp)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func decode(t *testing.T, buf []byte) *prompb.WriteRequest {
+	data, err := snappy.Decode(nil, buf)
+	require.NoError(t, err)
+	var req prompb.WriteRequest
+	require.NoError(t, proto.Unmarshal(data, &req))
+	return &req
+}
+
+func series(sample prompb.Sample, labels ...string) *prompb.TimeSeries {
+	ts := &prompb.TimeSeries{Samples: []*prompb.Sample{&sample}}
+	for i := 0; i < len(labels); i += 2 {
+		ts.Labels = append(ts.Labels, &prompb.Label{Name: labels[i], Value: labels[i+1]})
+	}
+	return ts
+}
+
+func TestSerializeBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   FormatConfig
+		metrics  []telegraf.Metric
+		expected []*prompb.TimeSeries
+	}{
+		{
+			name: "fields",
+			metrics: []telegraf.Metric{
+				mustMetric("cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{
+						"time_idle": 42.0,
+						"time_user": int64(43),
+						"state":     "ok",
+					},
+					time.Unix(1, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: []*prompb.TimeSeries{
+				series(prompb.Sample{Value: 42, Timestamp: 1000},
+					"__name__", "cpu_time_idle", "host", "example.org"),
+				series(prompb.Sample{Value: 43, Timestamp: 1000},
+					"__name__", "cpu_time_user", "host", "example.org"),
+			},
+		},
+		{
+			name: "counter from prometheus input",
+			metrics: []telegraf.Metric{
+				mustMetric("http_requests_total",
+					map[string]string{"code": "200"},
+					map[string]interface{}{"counter": 1027.0},
+					time.Unix(1, 0),
+					telegraf.Counter,
+				),
+			},
+			expected: []*prompb.TimeSeries{
+				series(prompb.Sample{Value: 1027, Timestamp: 1000},
+					"__name__", "http_requests_total", "code", "200"),
+			},
+		},
+		{
+			name: "samples of a series in time order",
+			metrics: []telegraf.Metric{
+				mustMetric("uptime",
+					map[string]string{},
+					map[string]interface{}{"value": 2.0},
+					time.Unix(2, 0),
+					telegraf.Untyped,
+				),
+				mustMetric("uptime",
+					map[string]string{},
+					map[string]interface{}{"value": 1.0},
+					time.Unix(1, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: []*prompb.TimeSeries{
+				{
+					Labels: []*prompb.Label{{Name: "__name__", Value: "uptime"}},
+					Samples: []*prompb.Sample{
+						{Value: 1, Timestamp: 1000},
+						{Value: 2, Timestamp: 2000},
+					},
+				},
+			},
+		},
+		{
+			name: "histogram",
+			metrics: []telegraf.Metric{
+				mustMetric("request_size_bytes",
+					map[string]string{},
+					map[string]interface{}{
+						"100":   3.0,
+						"sum":   4200.0,
+						"count": 6.0,
+					},
+					time.Unix(1, 0),
+					telegraf.Histogram,
+				),
+			},
+			expected: []*prompb.TimeSeries{
+				series(prompb.Sample{Value: 6, Timestamp: 1000},
+					"__name__", "request_size_bytes_bucket", "le", "+Inf"),
+				series(prompb.Sample{Value: 3, Timestamp: 1000},
+					"__name__", "request_size_bytes_bucket", "le", "100"),
+				series(prompb.Sample{Value: 6, Timestamp: 1000},
+					"__name__", "request_size_bytes_count"),
+				series(prompb.Sample{Value: 4200, Timestamp: 1000},
+					"__name__", "request_size_bytes_sum"),
+			},
+		},
+		{
+			name: "summary",
+			metrics: []telegraf.Metric{
+				mustMetric("rpc_duration_seconds",
+					map[string]string{},
+					map[string]interface{}{
+						"0.5":   0.0012,
+						"sum":   1.5,
+						"count": 100.0,
+					},
+					time.Unix(1, 0),
+					telegraf.Summary,
+				),
+			},
+			expected: []*prompb.TimeSeries{
+				series(prompb.Sample{Value: 0.0012, Timestamp: 1000},
+					"__name__", "rpc_duration_seconds", "quantile", "0.5"),
+				series(prompb.Sample{Value: 100, Timestamp: 1000},
+					"__name__", "rpc_duration_seconds_count"),
+				series(prompb.Sample{Value: 1.5, Timestamp: 1000},
+					"__name__", "rpc_duration_seconds_sum"),
+			},
+		},
+		{
+			name:   "string as label",
+			config: FormatConfig{StringAsLabel: true},
+			metrics: []telegraf.Metric{
+				mustMetric("cpu",
+					map[string]string{"__name__": "ignored", "device-name": "sda"},
+					map[string]interface{}{"time_idle": 42.0, "state": "ok"},
+					time.Unix(1, 0),
+					telegraf.Untyped,
+				),
+			},
+			expected: []*prompb.TimeSeries{
+				series(prompb.Sample{Value: 42, Timestamp: 1000},
+					"__name__", "cpu_time_idle", "device_name", "sda", "state", "ok"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.config)
+			require.NoError(t, err)
+			buf, err := s.SerializeBatch(tt.metrics)
+			require.NoError(t, err)
+			req := decode(t, buf)
+			require.Len(t, req.Timeseries, len(tt.expected))
+			for i := range tt.expected {
+				require.True(t, proto.Equal(tt.expected[i], req.Timeseries[i]),
+					"expected %v, got %v", tt.expected[i], req.Timeseries[i])
+			}
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
 	"github.com/influxdata/telegraf/plugins/serializers/nowmetric"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheusremotewrite"
 	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
 	"github.com/influxdata/telegraf/plugins/serializers/wavefront"
 )
@@ -79,6 +81,13 @@ type Config struct {
 	// Use Strict rules to sanitize metric and tag names from invalid characters for Wavefront
 	// When enabled forward slash (/) and comma (,) will be accepted
 	WavefrontUseStrict bool
+
+	// Include the metric timestamp on each sample; prometheus format only
+	PrometheusExportTimestamp bool
+
+	// Add string fields as labels; prometheus and prometheusremotewrite
+	// formats only
+	PrometheusStringAsLabel bool
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -100,12 +109,29 @@ func NewSerializer(config *Config) (Serializer, error) {
 		serializer, err = NewCarbon2Serializer()
 	case "wavefront":
 		serializer, err = NewWavefrontSerializer(config.Prefix, config.WavefrontUseStrict, config.WavefrontSourceOverride)
+	case "prometheus":
+		serializer, err = NewPrometheusSerializer(config)
+	case "prometheusremotewrite":
+		serializer, err = NewPrometheusRemoteWriteSerializer(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
 	return serializer, err
 }
 
+func NewPrometheusSerializer(config *Config) (Serializer, error) {
+	return prometheus.NewSerializer(prometheus.FormatConfig{
+		ExportTimestamp: config.PrometheusExportTimestamp,
+		StringAsLabel:   config.PrometheusStringAsLabel,
+	})
+}
+
+func NewPrometheusRemoteWriteSerializer(config *Config) (Serializer, error) {
+	return prometheusremotewrite.NewSerializer(prometheusremotewrite.FormatConfig{
+		StringAsLabel: config.PrometheusStringAsLabel,
+	})
+}
+
 func NewWavefrontSerializer(prefix string, useStrict bool, sourceOverride []string) (Serializer, error) {
 	return wavefront.NewSerializer(prefix, useStrict, sourceOverride)
 }
@@ -46,6 +46,9 @@ type Accumulator struct {
 	debug     bool
 	delivered chan telegraf.DeliveryInfo
 
+	// FieldMeta is the field metadata by measurement and field key.
+	FieldMeta map[string]map[string]telegraf.FieldMeta
+
 	TimeFunc func() time.Time
 }
 
@@ -183,6 +186,18 @@ func (a *Accumulator) AddMetric(m telegraf.Metric) {
 	a.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
 }
 
+func (a *Accumulator) SetFieldMeta(measurement, field string, meta telegraf.FieldMeta) {
+	a.Lock()
+	defer a.Unlock()
+	if a.FieldMeta == nil {
+		a.FieldMeta = make(map[string]map[string]telegraf.FieldMeta)
+	}
+	if a.FieldMeta[measurement] == nil {
+		a.FieldMeta[measurement] = make(map[string]telegraf.FieldMeta)
+	}
+	a.FieldMeta[measurement][field] = meta
+}
+
 func (a *Accumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
 	return a
 }